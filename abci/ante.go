@@ -0,0 +1,53 @@
+package abci
+
+import (
+	"fmt"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+// SealedBidDecorator records the sealed bid commitment made in the memo of a tx, on behalf of
+// its first signer. It must run after the signatures are verified.
+type SealedBidDecorator struct {
+	keeper weightskeeper.WeightsKeeper
+}
+
+func NewSealedBidDecorator(keeper weightskeeper.WeightsKeeper) SealedBidDecorator {
+	return SealedBidDecorator{keeper: keeper}
+}
+
+func (d SealedBidDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, simulate bool, next sdk.AnteHandler) (sdk.Context, error) {
+	// commitments are only recorded when the tx is included in a block
+	if simulate || ctx.ExecMode() != sdk.ExecModeFinalize || !d.keeper.GetParams(ctx).SealedBids {
+		return next(ctx, tx, simulate)
+	}
+
+	memoTx, ok := tx.(sdk.TxWithMemo)
+	if !ok {
+		return next(ctx, tx, simulate)
+	}
+	commitment, ok := parseSealedBidMemo(memoTx.GetMemo())
+	if !ok {
+		return next(ctx, tx, simulate)
+	}
+	sigTx, ok := tx.(authsigning.SigVerifiableTx)
+	if !ok {
+		return ctx, sdkerrors.ErrTxDecode.Wrap("sealed bid commitment tx without signers")
+	}
+	signers, err := sigTx.GetSigners()
+	if err != nil {
+		return ctx, err
+	}
+	if len(signers) == 0 {
+		return ctx, sdkerrors.ErrNoSignatures.Wrap("sealed bid commitment tx without signers")
+	}
+
+	bidder := sdk.AccAddress(signers[0]).String()
+	if err := d.keeper.AddSealedBid(ctx, commitment, bidder, ctx.BlockHeight()); err != nil {
+		return ctx, fmt.Errorf("failed to record sealed bid commitment: %w", err)
+	}
+	return next(ctx, tx, simulate)
+}
@@ -0,0 +1,109 @@
+package abci
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+/*
+	In commit-reveal mode the vote extension a validator sends at height H carries the hash of
+	the weights it computed at H and of the metrics it scored them from, and the one it sends
+	at H+1 reveals those weights and metrics along with the salt used for the hash. Only
+	reveals matching the commitment made one height earlier are aggregated, so the weights and
+	metrics visible in a vote extension are already locked in and cannot be copied by a lazy
+	validator.
+*/
+
+// pendingReveal holds the weights a validator committed to at a given height, along with the
+// metrics they were scored from
+type pendingReveal struct {
+	Weights    weightskeeper.ValidatorMap
+	Metrics    map[string]weightskeeper.ValidatorMap
+	Salt       []byte
+	Commitment []byte
+}
+
+// newPendingReveal commits to the given weights and metrics with a fresh random salt
+func newPendingReveal(weights map[string]int64, metrics map[string]weightskeeper.ValidatorMap) (pendingReveal, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return pendingReveal{}, err
+	}
+	commitment, err := reportCommitment(salt, weights, metrics)
+	if err != nil {
+		return pendingReveal{}, err
+	}
+	return pendingReveal{Weights: weights, Metrics: metrics, Salt: salt, Commitment: commitment}, nil
+}
+
+// reportCommitment returns the hash a validator commits to for the given weights and metrics.
+// Maps encode with sorted keys, so the same report always hashes the same.
+func reportCommitment(salt []byte, weights weightskeeper.ValidatorMap, metrics map[string]weightskeeper.ValidatorMap) ([]byte, error) {
+	bz, err := json.Marshal(struct {
+		Weights weightskeeper.ValidatorMap
+		Metrics map[string]weightskeeper.ValidatorMap
+	}{weights, metrics})
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(append(append([]byte{}, salt...), bz...))
+	return hash[:], nil
+}
+
+// revealMatches checks whether the weights, metrics and salt of a vote extension reveal the
+// given commitment
+func revealMatches(commitment []byte, voteExt WeightedVotingPowerVoteExtension) bool {
+	if len(commitment) == 0 || len(voteExt.Salt) == 0 {
+		return false
+	}
+	revealed, err := reportCommitment(voteExt.Salt, voteExt.Weights, voteExt.Metrics)
+	return err == nil && bytes.Equal(revealed, commitment)
+}
+
+// validateCommitment checks the shape of the commitment carried by a vote extension
+func validateCommitment(voteExt WeightedVotingPowerVoteExtension) error {
+	if len(voteExt.Commitment) != sha256.Size {
+		return fmt.Errorf("invalid commitment length %d", len(voteExt.Commitment))
+	}
+	return nil
+}
+
+// revealedReports keeps the reports whose reveal matches the commitment stored for the
+// reporting validator. Outside commit-reveal mode all reports are kept.
+func revealedReports(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, reports []validatorReport) ([]validatorReport, error) {
+	if !keeper.GetParams(ctx).CommitReveal {
+		return reports, nil
+	}
+
+	var revealed []validatorReport
+	for _, report := range reports {
+		commitment, err := keeper.GetCommitment(ctx, report.ConsAddr)
+		if err != nil {
+			return nil, err
+		}
+		if revealMatches(commitment, report.voteExt) {
+			revealed = append(revealed, report)
+		}
+	}
+	return revealed, nil
+}
+
+// storeCommitments stores the commitments of the given reports, to be checked against the
+// reveals of the next height
+func storeCommitments(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, reports []validatorReport) error {
+	commitments := make(map[string][]byte)
+	if keeper.GetParams(ctx).CommitReveal {
+		for _, report := range reports {
+			if len(report.voteExt.Commitment) > 0 {
+				commitments[string(report.ConsAddr)] = report.voteExt.Commitment
+			}
+		}
+	}
+	return keeper.SetCommitments(ctx, commitments)
+}
@@ -0,0 +1,52 @@
+package abci
+
+import (
+	"testing"
+
+	"cosmossdk.io/log"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitReveal(t *testing.T) {
+	proposer := NewVoteExtensionHandler(log.NewNopLogger(), weightskeeper.WeightsKeeper{}, govkeeper.Keeper{})
+	verifier := NewVoteExtensionHandler(log.NewNopLogger(), weightskeeper.WeightsKeeper{}, govkeeper.Keeper{})
+	validator := []byte("val1")
+
+	// the first extension only commits
+	metrics := map[string]weightskeeper.ValidatorMap{weightskeeper.MetricUptime: {"val1": 90}}
+	first, err := proposer.commitWeights(1, map[string]int64{"val1": 10}, metrics)
+	require.NoError(t, err)
+	require.Empty(t, first.Weights)
+	require.NoError(t, verifier.verifyReveal(1, validator, first))
+
+	// a later round at the same height keeps the commitment
+	again, err := proposer.commitWeights(1, map[string]int64{"val1": 99}, nil)
+	require.NoError(t, err)
+	require.Equal(t, first.Commitment, again.Commitment)
+
+	// the next extension reveals what was committed to
+	second, err := proposer.commitWeights(2, map[string]int64{"val1": 20}, nil)
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.ValidatorMap{"val1": 10}, second.Weights)
+	require.Equal(t, metrics, second.Metrics)
+	require.True(t, revealMatches(first.Commitment, second))
+
+	// a tampered reveal is rejected
+	tampered := second
+	tampered.Weights = map[string]int64{"val1": 55}
+	require.False(t, revealMatches(first.Commitment, tampered))
+	require.Error(t, verifier.verifyReveal(2, validator, tampered))
+
+	// so are metrics other than the ones committed to, copied from another validator
+	tampered = second
+	tampered.Metrics = map[string]weightskeeper.ValidatorMap{weightskeeper.MetricUptime: {"val1": 40}}
+	require.False(t, revealMatches(first.Commitment, tampered))
+	require.Error(t, verifier.verifyReveal(2, validator, tampered))
+	require.NoError(t, verifier.verifyReveal(2, validator, second))
+
+	// a malformed commitment is rejected
+	second.Commitment = []byte("short")
+	require.Error(t, verifier.verifyReveal(3, validator, second))
+}
@@ -0,0 +1,68 @@
+package abci
+
+import (
+	"testing"
+
+	storetypes "cosmossdk.io/store/types"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/stretchr/testify/require"
+)
+
+// setupKeeper creates a WeightsKeeper without staking backed by in-memory ws and params stores,
+// on a context with vote extensions enabled at the given height
+func setupKeeper(t *testing.T, voteExtensionsEnableHeight int64) (sdk.Context, weightskeeper.WeightsKeeper) {
+	t.Helper()
+
+	wsKey := storetypes.NewKVStoreKey(weight_shift.StoreKey)
+	paramsKey := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	paramsTKey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+	ctx := testutil.DefaultContextWithKeys(
+		map[string]*storetypes.KVStoreKey{wsKey.Name(): wsKey, paramsKey.Name(): paramsKey},
+		map[string]*storetypes.TransientStoreKey{paramsTKey.Name(): paramsTKey},
+		nil,
+	).WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: voteExtensionsEnableHeight},
+	})
+
+	encCfg := testutils.MakeTestEncodingConfig()
+	paramsKeeper := paramskeeper.NewKeeper(encCfg.Marshaler, encCfg.Amino, paramsKey, paramsTKey)
+	keeper := weightskeeper.NewWeightsKeeper(
+		encCfg.Marshaler,
+		addresscodec.NewBech32Codec(sdk.Bech32MainPrefix),
+		runtime.NewKVStoreService(wsKey),
+		paramsKeeper.Subspace(weight_shift.ModuleName),
+		nil,
+		nil,
+	)
+	return ctx, keeper
+}
+
+func TestWeightingEnabled(t *testing.T) {
+	// vote extensions are disabled with a zero enable height
+	ctx, keeper := setupKeeper(t, 0)
+	require.False(t, weightingEnabled(ctx, keeper, 100))
+
+	ctx, keeper = setupKeeper(t, 10)
+	require.False(t, weightingEnabled(ctx, keeper, 9))
+	require.True(t, weightingEnabled(ctx, keeper, 10))
+
+	// the ws enable height can only delay weighting
+	params := weightskeeper.DefaultParams()
+	params.EnableHeight = 20
+	keeper.SetParams(ctx, params)
+	require.False(t, weightingEnabled(ctx, keeper, 19))
+	require.True(t, weightingEnabled(ctx, keeper, 20))
+
+	params.EnableHeight = 5
+	keeper.SetParams(ctx, params)
+	require.False(t, weightingEnabled(ctx, keeper, 9))
+}
@@ -0,0 +1,105 @@
+package abci
+
+import (
+	"fmt"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// frontRun is a bid the proposer placed ahead of a bid for the same name that it outbids, the
+// pattern the tx provider's front-run strategy produces
+type frontRun struct {
+	Name        string
+	FrontRunner string
+	Victim      string
+}
+
+// detectFrontRuns returns the bids in txs that are front-run by a bid of the proposer
+func detectFrontRuns(proposer sdk.AccAddress, txs []sdk.Tx) []frontRun {
+	var (
+		proposerBids []*nstypes.MsgBid
+		frontRuns    []frontRun
+	)
+	for _, tx := range txs {
+		for _, msg := range tx.GetMsgs() {
+			bid, ok := msg.(*nstypes.MsgBid)
+			if !ok {
+				continue
+			}
+			if bid.Owner == proposer.String() {
+				proposerBids = append(proposerBids, bid)
+				continue
+			}
+			for _, earlier := range proposerBids {
+				if earlier.Name == bid.Name && earlier.Amount.IsAllGT(bid.Amount) {
+					frontRuns = append(frontRuns, frontRun{Name: bid.Name, FrontRunner: earlier.Owner, Victim: bid.Owner})
+					break
+				}
+			}
+		}
+	}
+	return frontRuns
+}
+
+// proposalFrontRuns returns the bids in the proposal front-run by its proposer, along with
+// the proposer's operator address. Validators can't see the account the proposer's tx
+// provider signs with, as it is part of the proposer's local config. The proposer is taken to
+// front-run with the account of its operator key, the key the provider signs with by
+// default, so bids placed from any other account are not detected.
+func (h *ProposalHandler) proposalFrontRuns(ctx sdk.Context, proposerAddr []byte, rawTxs [][]byte) (string, []frontRun) {
+	if h.txConfig == nil || len(rawTxs) == 0 {
+		return "", nil
+	}
+
+	proposer, err := h.keeper.GetValidatorByConsAddr(ctx, proposerAddr)
+	if err != nil {
+		h.logger.Error("failed to resolve the proposer", "err", err)
+		return "", nil
+	}
+	valAddr, err := sdk.ValAddressFromBech32(proposer.GetOperator())
+	if err != nil {
+		h.logger.Error("failed to decode the proposer's operator address", "err", err)
+		return "", nil
+	}
+
+	var txs []sdk.Tx
+	for _, rawTx := range rawTxs {
+		tx, err := h.txConfig.TxDecoder()(rawTx)
+		if err != nil {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return proposer.GetOperator(), detectFrontRuns(sdk.AccAddress(valAddr), txs)
+}
+
+// rejectFrontRuns reports whether the proposal should be rejected for the bids its proposer
+// front-runs
+func (h *ProposalHandler) rejectFrontRuns(ctx sdk.Context, proposerAddr []byte, rawTxs [][]byte) bool {
+	if !h.keeper.GetParams(ctx).RejectFrontRuns {
+		return false
+	}
+	_, frontRuns := h.proposalFrontRuns(ctx, proposerAddr, rawTxs)
+	for _, fr := range frontRuns {
+		h.logger.Info(fmt.Sprintf("🚨 :: Rejecting proposal front-running the bid of %s for %s", fr.Victim, fr.Name))
+	}
+	return len(frontRuns) > 0
+}
+
+// emitFrontRuns flags the bids the proposer of the block front-runs with an event. It runs
+// once the block is decided, as the events of ProcessProposal are discarded.
+func (h *ProposalHandler) emitFrontRuns(ctx sdk.Context, proposerAddr []byte, rawTxs [][]byte) {
+	proposer, frontRuns := h.proposalFrontRuns(ctx, proposerAddr, rawTxs)
+	for _, fr := range frontRuns {
+		h.logger.Info(fmt.Sprintf("🚨 :: Proposer front-ran the bid of %s for %s", fr.Victim, fr.Name))
+		ctx.EventManager().EmitEvent(sdk.NewEvent(
+			weightskeeper.EventTypeFrontRun,
+			sdk.NewAttribute(weightskeeper.AttributeKeyValidator, proposer),
+			sdk.NewAttribute(weightskeeper.AttributeKeyName, fr.Name),
+			sdk.NewAttribute(weightskeeper.AttributeKeyFrontRunner, fr.FrontRunner),
+			sdk.NewAttribute(weightskeeper.AttributeKeyVictim, fr.Victim),
+		))
+	}
+}
@@ -0,0 +1,43 @@
+package abci
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+// msgsTx is a tx made only of its messages
+type msgsTx []sdk.Msg
+
+func (tx msgsTx) GetMsgs() []sdk.Msg                    { return tx }
+func (tx msgsTx) GetMsgsV2() ([]protov2.Message, error) { return nil, nil }
+
+func bidTx(name string, owner sdk.AccAddress, amount int64) sdk.Tx {
+	return msgsTx{&nstypes.MsgBid{Name: name, Owner: owner.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", amount))}}
+}
+
+func TestDetectFrontRuns(t *testing.T) {
+	proposer := sdk.AccAddress("proposer____________")
+	alice := sdk.AccAddress("alice_______________")
+	bob := sdk.AccAddress("bob_________________")
+
+	// the proposer outbids alice right in front of her bid
+	frontRuns := detectFrontRuns(proposer, []sdk.Tx{
+		bidTx("alice.ns", proposer, 200),
+		bidTx("alice.ns", alice, 100),
+		bidTx("bob.ns", bob, 100),
+	})
+	require.Equal(t, []frontRun{{Name: "alice.ns", FrontRunner: proposer.String(), Victim: alice.String()}}, frontRuns)
+
+	// bids placed after, for other names or not outbidding are fair game
+	require.Empty(t, detectFrontRuns(proposer, []sdk.Tx{
+		bidTx("alice.ns", alice, 100),
+		bidTx("alice.ns", proposer, 200),
+		bidTx("bob.ns", proposer, 200),
+		bidTx("carol.ns", proposer, 100),
+		bidTx("carol.ns", bob, 100),
+	}))
+}
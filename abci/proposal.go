@@ -1,97 +1,88 @@
 package abci
 
 import (
+	"bytes"
 	"cosmossdk.io/log"
-	"cosmossdk.io/math"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ciprianmuja/weight-shift/mempool"
+	"github.com/ciprianmuja/weight-shift/provider"
 	"github.com/ciprianmuja/weight-shift/weightskeeper"
 	abci "github.com/cometbft/cometbft/abci/types"
 	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
 	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client"
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	stakingkeeper "github.com/cosmos/cosmos-sdk/x/staking/keeper"
+	"sort"
+	"time"
 )
 
 // WeightedVotingPower defines the structure a proposer should use to calculate
 // and submit the weighted voting power for the given validator set
 type WeightedVotingPower struct {
-	StakeWeightedWeighted map[string]int64
+	// StakeWeightedWeighted holds the aggregated weight of each validator, keyed by consensus
+	// address
+	StakeWeightedWeighted weightskeeper.ValidatorMap
 	ExtendedCommitInfo    abci.ExtendedCommitInfo
 }
 
+// IsInjectedTx reports whether the given tx bytes decode as the weights tx a proposer injects
+// at the top of its proposal. Such bytes never decode as an sdk.Tx, so they can't reach the
+// ante handler and are only caught when a proposal is processed.
+func IsInjectedTx(bz []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bz, &fields); err != nil {
+		return false
+	}
+	_, hasWeights := fields["StakeWeightedWeighted"]
+	_, hasCommitInfo := fields["ExtendedCommitInfo"]
+	return hasWeights || hasCommitInfo
+}
+
 type ProposalHandler struct {
-	logger        log.Logger
-	keeper        weightskeeper.WeightsKeeper
-	stakingKeeper *stakingkeeper.Keeper
-	valStore      baseapp.ValidatorStore
+	logger   log.Logger
+	keeper   weightskeeper.WeightsKeeper
+	valStore baseapp.ValidatorStore
+	txConfig client.TxConfig
+	// txProvider is nil unless the node runs the transaction provider
+	txProvider provider.TxProvider
+	// laneMempool is nil unless the app uses the lane mempool
+	laneMempool *mempool.LaneMempool
+	// bidExecutor executes revealed sealed bids, which are dropped when it is nil
+	bidExecutor BidExecutor
 }
 
 func NewPrepareProposalHandler(logger log.Logger, keeper weightskeeper.WeightsKeeper, valStore baseapp.ValidatorStore,
-	stakingKeeper *stakingkeeper.Keeper) *ProposalHandler {
+	txConfig client.TxConfig, txProvider provider.TxProvider) *ProposalHandler {
 	return &ProposalHandler{
-		logger:        logger,
-		keeper:        keeper,
-		valStore:      valStore,
-		stakingKeeper: stakingKeeper,
+		logger:     logger,
+		keeper:     keeper,
+		valStore:   valStore,
+		txConfig:   txConfig,
+		txProvider: txProvider,
 	}
 }
 
-func processVoteExtensions(req *abci.RequestPrepareProposal, log log.Logger) (WeightedVotingPowerVoteExtension, error) {
-	log.Info(fmt.Sprintf("🛠️ :: Process Vote Extensions"))
-
-	// Create empty response
-	st := WeightedVotingPowerVoteExtension{
-		map[string]int64{},
-	}
-
-	// Get Vote Ext for H-1 from Req
-	voteExt := req.GetLocalLastCommit()
-	votes := voteExt.Votes
-
-	// Iterate through votes
-	var ve WeightedVotingPowerVoteExtension
-	for _, vote := range votes {
-		err := json.Unmarshal(vote.VoteExtension, &ve)
-		if err != nil {
-			log.Error(fmt.Sprintf("❌ :: Error unmarshalling Vote Extension"))
-		}
-
-		// If Bids in VE, append to Special Transaction
-		if len(ve.Weights) > 0 {
-			log.Info("🛠️ :: Weights in VE")
-			st.Weights = ve.Weights
-		}
-	}
+// SetLaneMempool makes the handler fill proposals from the lanes of the given mempool instead
+// of the txs CometBFT passes in
+func (h *ProposalHandler) SetLaneMempool(laneMempool *mempool.LaneMempool) {
+	h.laneMempool = laneMempool
+}
 
-	return st, nil
+// SetBidExecutor sets how the sealed bids revealed in vote extensions are executed
+func (h *ProposalHandler) SetBidExecutor(executor BidExecutor) {
+	h.bidExecutor = executor
 }
 
 func (h *ProposalHandler) PrepareProposal() sdk.PrepareProposalHandler {
 	return func(ctx sdk.Context, req *abci.RequestPrepareProposal) (*abci.ResponsePrepareProposal, error) {
+		defer measureSince(time.Now(), metricKeyPrepareProposal)
 		var proposalTxs [][]byte
-		if req.Height > 2 {
-
-			// Get Special Transaction
-			ve, err := processVoteExtensions(req, h.logger)
-			if err != nil {
-				h.logger.Error(fmt.Sprintf("❌️ :: Unable to process Vote Extensions: %v", err))
-			}
-
-			// Marshal Special Transaction
-			bz, err := json.Marshal(ve)
-			if err != nil {
-				h.logger.Error(fmt.Sprintf("❌️ :: Unable to marshal Vote Extensions: %v", err))
-			}
-
-			// Append Special Transaction to proposal
-			proposalTxs = append(proposalTxs, bz)
-		}
 
 		// if the current height does not have vote extensions enabled, skip it
 
-		if req.Height >= ctx.ConsensusParams().Abci.VoteExtensionsEnableHeight {
+		if injectsWeights(ctx, h.keeper, req.Height) {
 			h.logger.Info(fmt.Sprintf("⚙️ :: Prepare Proposal"))
 
 			// compute the weighted voting power
@@ -100,12 +91,8 @@ func (h *ProposalHandler) PrepareProposal() sdk.PrepareProposalHandler {
 				return nil, err
 			}
 
-			for key, value := range weightedsVotingPower {
-				h.logger.Info(fmt.Sprintf("%s:%d", key, value))
-			}
-
-			if err != nil {
-				return nil, errors.New("failed to compute weights")
+			for validator, weight := range weightedsVotingPower {
+				h.logger.Debug("aggregated weight", "validator", sdk.ConsAddress(validator).String(), "weight", weight)
 			}
 
 			injectedVoteExtTx := WeightedVotingPower{
@@ -125,85 +112,496 @@ func (h *ProposalHandler) PrepareProposal() sdk.PrepareProposalHandler {
 			proposalTxs = append(proposalTxs, bz)
 		}
 
-		// keep the original txs
+		// keep the original txs, or take them lane by lane from the lane mempool, letting the
+		// provider rework them on nodes that run it
+		txs := req.Txs
+		maxTxBytes := req.MaxTxBytes - txsSize(proposalTxs)
+		if h.laneMempool != nil {
+			var err error
+			txs, err = h.laneMempool.Fill(ctx, h.txConfig.TxEncoder(), maxTxBytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if h.txProvider != nil {
+			var err error
+			txs, err = h.buildProposal(ctx, txs)
+			if err != nil {
+				return nil, err
+			}
+			// the provider adds txs of its own, which must keep to the share of their lane too
+			if h.laneMempool != nil {
+				txs, err = h.laneMempool.Trim(txs, h.txConfig.TxDecoder(), maxTxBytes)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// the injected weights tx comes first, the other txs fill the rest of the block
+		injected := len(proposalTxs)
+		proposalTxs = appendWithinLimit(proposalTxs, txs, req.MaxTxBytes)
+		if dropped := len(txs) - (len(proposalTxs) - injected); dropped > 0 {
+			h.logger.Info(fmt.Sprintf("⚙️ :: Dropped %d txs exceeding the max tx bytes", dropped))
+		}
+
 		return &abci.ResponsePrepareProposal{
 			Txs: proposalTxs,
 		}, nil
 	}
 }
 
+// injectsWeights reports whether the proposal at the given height starts with the injected
+// weights tx, which is the case once weighting is enabled
+func injectsWeights(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, height int64) bool {
+	return weightingEnabled(ctx, keeper, height)
+}
+
+// weightingEnabled reports whether validators report weights and the reported weights are
+// applied at the given height. Weighting starts once vote extensions are enabled in the
+// consensus params, which genesis or an x/consensus MsgUpdateParams proposal can do, and the
+// ws enable height is reached.
+func weightingEnabled(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, height int64) bool {
+	abciParams := ctx.ConsensusParams().Abci
+	if abciParams == nil || abciParams.VoteExtensionsEnableHeight <= 0 || height < abciParams.VoteExtensionsEnableHeight {
+		return false
+	}
+	return height >= keeper.GetParams(ctx).EnableHeight
+}
+
+// txsSize returns the total size of the given txs
+func txsSize(txs [][]byte) int64 {
+	var size int64
+	for _, tx := range txs {
+		size += int64(len(tx))
+	}
+	return size
+}
+
+// appendWithinLimit appends txs to the proposal in order until the next one would take the
+// proposal over maxTxBytes
+func appendWithinLimit(proposalTxs, txs [][]byte, maxTxBytes int64) [][]byte {
+	size := txsSize(proposalTxs)
+	for _, tx := range txs {
+		size += int64(len(tx))
+		if size > maxTxBytes {
+			break
+		}
+		proposalTxs = append(proposalTxs, tx)
+	}
+	return proposalTxs
+}
+
+// buildProposal runs the mempool txs through the tx provider and returns them encoded
+func (h *ProposalHandler) buildProposal(ctx sdk.Context, rawTxs [][]byte) ([][]byte, error) {
+	var txs []sdk.Tx
+	for _, rawTx := range rawTxs {
+		tx, err := h.txConfig.TxDecoder()(rawTx)
+		if err != nil {
+			h.logger.Error("failed to decode proposal tx", "err", err)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+
+	txs, err := h.txProvider.BuildProposal(ctx, txs)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([][]byte, 0, len(txs))
+	for _, tx := range txs {
+		bz, err := h.txConfig.TxEncoder()(tx)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, bz)
+	}
+	return encoded, nil
+}
+
 func (h *ProposalHandler) ProcessProposal() sdk.ProcessProposalHandler {
 	return func(ctx sdk.Context, req *abci.RequestProcessProposal) (*abci.ResponseProcessProposal, error) {
+		defer measureSince(time.Now(), metricKeyProcessProposal)
 		h.logger.Info(fmt.Sprintf("⚙️ :: Process Proposal"))
-		if len(req.Txs) == 0 {
-			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_ACCEPT}, nil
+
+		txs := req.Txs
+		if injectsWeights(ctx, h.keeper, req.Height) {
+			// the proposer must inject the weights, even when there are no other txs
+			if len(txs) == 0 || !IsInjectedTx(txs[0]) {
+				h.logger.Error("rejecting proposal without the injected vote extension tx")
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+			var injectedVoteExtTx WeightedVotingPower
+			if err := json.Unmarshal(txs[0], &injectedVoteExtTx); err != nil {
+				h.logger.Error("failed to decode injected vote extension tx", "err", err)
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+			if err := h.verifyInjectedTx(ctx, req, injectedVoteExtTx); err != nil {
+				h.logger.Error("rejecting proposal with invalid injected vote extension tx", "err", err)
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+			txs = txs[1:]
 		}
 
-		var injectedVoteExtTx WeightedVotingPower
-		if err := json.Unmarshal(req.Txs[0], &injectedVoteExtTx); err != nil {
-			h.logger.Error("failed to decode injected vote extension tx", "err", err)
+		// only the tx at the top of the proposal may carry the weights
+		for _, tx := range txs {
+			if IsInjectedTx(tx) {
+				h.logger.Error("proposal carries an injected vote extension tx out of place")
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+		}
+
+		if h.rejectFrontRuns(ctx, req.ProposerAddress, txs) {
 			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
 		}
-		//h.logger.Info(fmt.Sprint(injectedVoteExtTx))
 
 		return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_ACCEPT}, nil
 	}
 }
-func (h *ProposalHandler) processWeightedVotingPowerVoteExtensions(ctx sdk.Context, ci abci.ExtendedCommitInfo) (map[string]int64, error) {
 
-	weightedVoting := make(map[string]int64)
+// verifyInjectedTx checks that the injected tx carries the commit CometBFT decided on for the
+// last height, with every vote extension signed by its validator and valid, and that the
+// weights it injects are the ones aggregated from those vote extensions
+func (h *ProposalHandler) verifyInjectedTx(ctx sdk.Context, req *abci.RequestProcessProposal, injected WeightedVotingPower) error {
+	ci := injected.ExtendedCommitInfo
+	if err := matchesLastCommit(ci, req.ProposedLastCommit); err != nil {
+		return err
+	}
+	if err := validateVoteExtensions(ctx, h.valStore, req.Height, ci); err != nil {
+		return err
+	}
+	for _, vote := range ci.Votes {
+		if vote.BlockIdFlag != cmtproto.BlockIDFlagCommit || len(vote.VoteExtension) == 0 {
+			continue
+		}
+		if _, _, err := decodeVoteExtension(vote.VoteExtension, req.Height-1); err != nil {
+			return fmt.Errorf("invalid vote extension from validator %X: %w", vote.Validator.Address, err)
+		}
+	}
+
+	weights, err := h.processWeightedVotingPowerVoteExtensions(ctx, ci)
+	if err != nil {
+		return err
+	}
+	if !equalWeights(weights, injected.StakeWeightedWeighted) {
+		return errors.New("injected weights do not match the vote extensions")
+	}
+	return nil
+}
+
+// matchesLastCommit checks that the commit carried by the injected tx has the same votes as
+// the last commit of the proposal, so that no vote can be added or left out
+func matchesLastCommit(ci abci.ExtendedCommitInfo, lastCommit abci.CommitInfo) error {
+	if ci.Round != lastCommit.Round || len(ci.Votes) != len(lastCommit.Votes) {
+		return errors.New("injected commit does not match the last commit")
+	}
+	for i, vote := range ci.Votes {
+		last := lastCommit.Votes[i]
+		if !bytes.Equal(vote.Validator.Address, last.Validator.Address) ||
+			vote.Validator.Power != last.Validator.Power || vote.BlockIdFlag != last.BlockIdFlag {
+			return fmt.Errorf("injected vote of validator %X does not match the last commit", vote.Validator.Address)
+		}
+	}
+	return nil
+}
+
+// validateVoteExtensions checks the signatures of the vote extensions made at the height
+// before the given one. The votes of a height vote extensions were not enabled at carry none.
+func validateVoteExtensions(ctx sdk.Context, valStore baseapp.ValidatorStore, height int64, ci abci.ExtendedCommitInfo) error {
+	abciParams := ctx.ConsensusParams().Abci
+	if abciParams == nil || abciParams.VoteExtensionsEnableHeight <= 0 || height-1 < abciParams.VoteExtensionsEnableHeight {
+		for _, vote := range ci.Votes {
+			if len(vote.VoteExtension) > 0 || len(vote.ExtensionSignature) > 0 {
+				return fmt.Errorf("validator %X extended its vote before vote extensions were enabled", vote.Validator.Address)
+			}
+		}
+		return nil
+	}
+	return baseapp.ValidateVoteExtensions(ctx, valStore, height, ctx.ChainID(), ci)
+}
+
+// equalWeights reports whether both weights hold the same weight for the same validators
+func equalWeights(a, b map[string]int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for validator, weight := range a {
+		if other, ok := b[validator]; !ok || other != weight {
+			return false
+		}
+	}
+	return true
+}
 
+// processWeightedVotingPowerVoteExtensions aggregates the weights reported in the vote
+// extensions of the last commit into their stake weighted median
+func (h *ProposalHandler) processWeightedVotingPowerVoteExtensions(ctx sdk.Context, ci abci.ExtendedCommitInfo) (map[string]int64, error) {
 	h.logger.Info(fmt.Sprintf("found %d votes", len(ci.Votes)))
 
+	reports, err := revealedReports(ctx, h.keeper, decodeReports(ci, h.logger))
+	if err != nil {
+		return nil, err
+	}
+	return stakeWeightedMedians(reports), nil
+}
+
+// validatorReport holds the weights a validator reported in its vote extension along with the
+// voting power the validator had in the commit
+type validatorReport struct {
+	weightskeeper.Report
+	Power   int64
+	voteExt WeightedVotingPowerVoteExtension
+}
+
+// decodeReports decodes the vote extensions of the committed votes, skipping votes without
+// a valid extension
+func decodeReports(ci abci.ExtendedCommitInfo, logger log.Logger) []validatorReport {
+	var reports []validatorReport
 	for _, v := range ci.Votes {
 		if v.BlockIdFlag != cmtproto.BlockIDFlagCommit {
-			h.logger.Info("skipping BlockIDFlagCommit")
+			logger.Info("skipping vote without BlockIDFlagCommit")
+			continue
+		}
+		if len(v.VoteExtension) == 0 {
+			continue
+		}
+
+		var voteExt WeightedVotingPowerVoteExtension
+		if err := json.Unmarshal(v.VoteExtension, &voteExt); err != nil {
+			logger.Error("failed to decode vote extension", "err", err, "validator", fmt.Sprintf("%x", v.Validator.Address))
+			continue
+		}
+
+		reports = append(reports, validatorReport{
+			Report: weightskeeper.Report{
+				ConsAddr: v.Validator.Address,
+				Weights:  voteExt.Weights,
+			},
+			Power:   v.Validator.Power,
+			voteExt: voteExt,
+		})
+	}
+	return reports
+}
+
+// rejection is why a committed vote extension was left out of the aggregated weights
+type rejection struct {
+	reason  string
+	message string
+}
+
+// emitRejectedVoteExtensions emits an EventVoteExtensionRejected for every vote extension of
+// the given height's commit left out of the aggregated weights
+func emitRejectedVoteExtensions(ctx sdk.Context, height int64, ci abci.ExtendedCommitInfo, decoded, revealed []validatorReport) error {
+	rejections := make(map[string]rejection)
+	for _, v := range ci.Votes {
+		if v.BlockIdFlag == cmtproto.BlockIDFlagCommit && len(v.VoteExtension) > 0 {
+			rejections[string(v.Validator.Address)] = rejection{rejectReasonDecode, "invalid vote extension"}
+		}
+	}
+	for _, report := range decoded {
+		rejections[string(report.ConsAddr)] = rejection{rejectReasonReveal, "reveal does not match the previous commitment"}
+	}
+	for _, report := range revealed {
+		delete(rejections, string(report.ConsAddr))
+	}
+
+	for _, v := range ci.Votes {
+		rejected, ok := rejections[string(v.Validator.Address)]
+		if !ok {
 			continue
 		}
+		delete(rejections, string(v.Validator.Address))
+		incrRejectedVoteExtensions(rejectStageAggregate, rejected.reason)
+		if err := ctx.EventManager().EmitTypedEvent(&weightskeeper.EventVoteExtensionRejected{
+			Validator: sdk.ConsAddress(v.Validator.Address).String(),
+			Height:    height,
+			Reason:    rejected.message,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type poweredWeight struct {
+	weight int64
+	power  int64
+}
 
-		h.logger.Info(fmt.Sprintf("Vote Extension: %s", v.String()))
-		h.logger.Info(fmt.Sprint(v.VoteExtension))
+// rescoreReports scores the weights of every report again from the metrics it carries with the
+// current metric coefficients, the way ExtendVote does: validators without metrics keep the
+// weight they have
+func rescoreReports(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, reports []validatorReport) ([]validatorReport, error) {
+	stored, err := keeper.GetWeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	coefficients := keeper.GetParams(ctx).MetricCoefficients
 
-		if len(v.VoteExtension) <= 0 {
-			//h.logger.Error("no vote extensions")
-			return nil, nil
+	rescored := make([]validatorReport, 0, len(reports))
+	for _, report := range reports {
+		weights := make(map[string]int64, len(stored))
+		for validator, weight := range stored {
+			weights[validator] = weight
+		}
+		scored, _ := weightskeeper.ScoreMetrics(report.voteExt.Metrics, coefficients)
+		for validator, weight := range scored {
+			weights[validator] = weight
 		}
+		report.Weights = weights
+		rescored = append(rescored, report)
+	}
+	return rescored, nil
+}
 
-		var voteExt WeightedVotingPower
-		if err := json.Unmarshal(v.VoteExtension, &voteExt); err != nil {
-			h.logger.Error(err.Error())
-			h.logger.Error("failed to decode vote extension", "err", err, "validator", fmt.Sprintf("%x", v.Validator.Address))
-			return nil, err
+// stakeWeightedMedians returns for every reported validator the stake weighted median of the
+// weights reported for it
+func stakeWeightedMedians(reports []validatorReport) map[string]int64 {
+	reported := make(map[string][]poweredWeight)
+	for _, report := range reports {
+		for validator, weight := range report.Weights {
+			reported[validator] = append(reported[validator], poweredWeight{weight: weight, power: report.Power})
 		}
 	}
 
-	return weightedVoting, nil
+	medians := make(map[string]int64, len(reported))
+	for validator, weights := range reported {
+		medians[validator] = stakeWeightedMedian(weights)
+	}
+	return medians
+}
+
+// stakeWeightedMetricMedians returns, for every metric and validator, the stake weighted median
+// of the raw values reported for it
+func stakeWeightedMetricMedians(reports []validatorReport) map[string]weightskeeper.ValidatorMap {
+	reported := make(map[string]map[string][]poweredWeight)
+	for _, report := range reports {
+		for metric, values := range report.voteExt.Metrics {
+			if reported[metric] == nil {
+				reported[metric] = make(map[string][]poweredWeight)
+			}
+			for validator, value := range values {
+				reported[metric][validator] = append(reported[metric][validator], poweredWeight{weight: value, power: report.Power})
+			}
+		}
+	}
+
+	medians := make(map[string]weightskeeper.ValidatorMap, len(reported))
+	for metric, values := range reported {
+		medians[metric] = make(weightskeeper.ValidatorMap, len(values))
+		for validator, weights := range values {
+			medians[metric][validator] = stakeWeightedMedian(weights)
+		}
+	}
+	return medians
+}
+
+// stakeWeightedMedian returns the weight at which half of the reporting power is reached
+func stakeWeightedMedian(weights []poweredWeight) int64 {
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].weight != weights[j].weight {
+			return weights[i].weight < weights[j].weight
+		}
+		return weights[i].power < weights[j].power
+	})
+
+	var total int64
+	for _, w := range weights {
+		total += w.power
+	}
+
+	var cumulative int64
+	for _, w := range weights {
+		cumulative += w.power
+		if cumulative*2 >= total {
+			return w.weight
+		}
+	}
+	return 0
 }
 
 func (h *ProposalHandler) PreBlocker(ctx sdk.Context, req *abci.RequestFinalizeBlock) (*sdk.ResponsePreBlock, error) {
+	return h.preBlock(ctx, req, false)
+}
+
+// ReplayPreBlocker runs the PreBlocker on a committed block as if its vote extensions had been
+// made with the current params: the weights of every report are scored again from the metrics
+// reported along with them and aggregated in place of the weights the proposer injected. It
+// lets candidate metric coefficients be replayed over the recorded metrics.
+func (h *ProposalHandler) ReplayPreBlocker(ctx sdk.Context, req *abci.RequestFinalizeBlock) (*sdk.ResponsePreBlock, error) {
+	return h.preBlock(ctx, req, true)
+}
+
+func (h *ProposalHandler) preBlock(ctx sdk.Context, req *abci.RequestFinalizeBlock, rescore bool) (*sdk.ResponsePreBlock, error) {
 	res := &sdk.ResponsePreBlock{}
-	if len(req.Txs) == 0 {
+
+	// close the previous epoch before recording the reports of this block
+	if err := h.keeper.ProcessOutliers(ctx); err != nil {
+		return nil, err
+	}
+	h.emitFrontRuns(ctx, req.ProposerAddress, req.Txs)
+
+	if len(req.Txs) == 0 || !injectsWeights(ctx, h.keeper, req.Height) {
 		return res, nil
 	}
 
-	var injectedVoteExtTx WeightedVotingPowerVoteExtension
+	var injectedVoteExtTx WeightedVotingPower
 	if err := json.Unmarshal(req.Txs[0], &injectedVoteExtTx); err != nil {
 		h.logger.Error("failed to decode injected vote extension tx", "err", err)
 		return nil, err
 	}
 
-	// set weights using the passed in context, which will make these weighted voting power available in the current block
-	if err := h.keeper.SetWeights(ctx, injectedVoteExtTx.Weights); err != nil {
+	// in commit-reveal mode only the reports revealing last height's commitment count, the
+	// commitments made in this commit are kept for the next height
+	decoded := decodeReports(injectedVoteExtTx.ExtendedCommitInfo, h.logger)
+	revealed, err := revealedReports(ctx, h.keeper, decoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeCommitments(ctx, h.keeper, decoded); err != nil {
+		return nil, err
+	}
+	if err := emitRejectedVoteExtensions(ctx, req.Height-1, injectedVoteExtTx.ExtendedCommitInfo, decoded, revealed); err != nil {
 		return nil, err
 	}
 
-	// handle the weights logic to increase and decrease the voting power of the validators
-	for valAddress, weight := range injectedVoteExtTx.Weights {
-		h.logger.Info(fmt.Sprintf("%s: %d", valAddress, weight))
-		if h.stakingKeeper != nil {
-			h.stakingKeeper.SetLastTotalPower(ctx, math.NewInt(weight))
+	// sealed bids revealed in the last commit run ahead of the txs of this block
+	if err := h.processSealedBids(ctx, injectedVoteExtTx.ExtendedCommitInfo); err != nil {
+		return nil, err
+	}
+
+	aggregated := injectedVoteExtTx.StakeWeightedWeighted
+	if rescore {
+		if revealed, err = rescoreReports(ctx, h.keeper, revealed); err != nil {
+			return nil, err
 		}
+		aggregated = stakeWeightedMedians(revealed)
+	}
+
+	// record how far each validator's report deviated from the aggregated weights
+	var reports []weightskeeper.Report
+	for _, report := range revealed {
+		reports = append(reports, report.Report)
+	}
+	if err := h.keeper.RecordDeviations(ctx, aggregated, reports); err != nil {
+		return nil, err
+	}
+
+	// validators still in their grace period get the median weight of the set, the penalties
+	// of validators that kept reporting outliers are deducted and no validator gets more than
+	// the max weight, whatever the proposer injected
+	weights, err := h.keeper.DeriveWeights(ctx, aggregated, stakeWeightedMetricMedians(revealed))
+	if err != nil {
+		return nil, err
+	}
+
+	// set weights using the passed in context, which will make these weighted voting power available in the current block
+	if err := h.keeper.SetWeights(ctx, weights); err != nil {
+		return nil, err
 	}
+	setWeightGauges(ctx, h.keeper, weights)
 
 	return res, nil
 }
@@ -0,0 +1,123 @@
+package abci
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cosmossdk.io/log"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func extendedVote(t *testing.T, addr string, power int64, weights map[string]int64) abci.ExtendedVoteInfo {
+	t.Helper()
+	bz, err := json.Marshal(WeightedVotingPowerVoteExtension{Weights: weights})
+	require.NoError(t, err)
+	return abci.ExtendedVoteInfo{
+		Validator:     abci.Validator{Address: []byte(addr), Power: power},
+		VoteExtension: bz,
+		BlockIdFlag:   cmtproto.BlockIDFlagCommit,
+	}
+}
+
+func TestIsInjectedTx(t *testing.T) {
+	injected, err := json.Marshal(WeightedVotingPower{StakeWeightedWeighted: map[string]int64{"val": 10}})
+	require.NoError(t, err)
+	require.True(t, IsInjectedTx(injected))
+	require.True(t, IsInjectedTx([]byte(`{"ExtendedCommitInfo":{}}`)))
+
+	require.False(t, IsInjectedTx([]byte(`{"body":{}}`)))
+	require.False(t, IsInjectedTx([]byte(`["StakeWeightedWeighted"]`)))
+	require.False(t, IsInjectedTx([]byte{0x0a, 0x02}))
+}
+
+func TestProcessProposalRequiresInjectedTx(t *testing.T) {
+	ctx, keeper := setupKeeper(t, 1)
+	h := NewPrepareProposalHandler(log.NewNopLogger(), keeper, nil, nil, nil)
+
+	// once weighting is enabled a proposal without the injected tx on top is rejected, empty or not
+	for _, txs := range [][][]byte{nil, {[]byte("tx")}} {
+		res, err := h.ProcessProposal()(ctx, &abci.RequestProcessProposal{Height: 2, Txs: txs})
+		require.NoError(t, err)
+		require.Equal(t, abci.ResponseProcessProposal_REJECT, res.Status)
+	}
+
+	// before weighting is enabled an empty proposal is fine
+	ctx, keeper = setupKeeper(t, 0)
+	h = NewPrepareProposalHandler(log.NewNopLogger(), keeper, nil, nil, nil)
+	res, err := h.ProcessProposal()(ctx, &abci.RequestProcessProposal{Height: 2})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_ACCEPT, res.Status)
+}
+
+func TestStakeWeightedMedians(t *testing.T) {
+	ci := abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		extendedVote(t, "val1", 10, map[string]int64{"val1": 40, "val2": 10}),
+		extendedVote(t, "val2", 10, map[string]int64{"val1": 30, "val2": 20}),
+		// the heaviest validator decides the median on its own
+		extendedVote(t, "val3", 30, map[string]int64{"val1": 5, "val2": 50}),
+		// absent and undecodable votes are ignored
+		{Validator: abci.Validator{Address: []byte("val4"), Power: 100}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+		{Validator: abci.Validator{Address: []byte("val5"), Power: 100}, VoteExtension: []byte("{"), BlockIdFlag: cmtproto.BlockIDFlagCommit},
+	}}
+
+	reports := decodeReports(ci, log.NewNopLogger())
+	require.Len(t, reports, 3)
+	require.Equal(t, map[string]int64{"val1": 5, "val2": 50}, stakeWeightedMedians(reports))
+}
+
+func TestAppendWithinLimit(t *testing.T) {
+	injected := [][]byte{[]byte("weights")}
+	txs := [][]byte{[]byte("aaa"), []byte("bbbb"), []byte("c")}
+
+	require.Equal(t, append(injected, txs...), appendWithinLimit(injected, txs, 15))
+	// the injected tx counts against the limit and the txs after the first that doesn't fit are dropped
+	require.Equal(t, [][]byte{[]byte("weights"), []byte("aaa")}, appendWithinLimit(injected, txs, 13))
+	require.Empty(t, appendWithinLimit(nil, txs, 2))
+}
+
+func TestEmitRejectedVoteExtensions(t *testing.T) {
+	ci := abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		extendedVote(t, "val1", 10, map[string]int64{"val1": 40}),
+		extendedVote(t, "val2", 10, map[string]int64{"val1": 30}),
+		{Validator: abci.Validator{Address: []byte("val3"), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+		{Validator: abci.Validator{Address: []byte("val4"), Power: 10}, VoteExtension: []byte("{"), BlockIdFlag: cmtproto.BlockIDFlagCommit},
+	}}
+	decoded := decodeReports(ci, log.NewNopLogger())
+	ctx := sdk.Context{}.WithEventManager(sdk.NewEventManager())
+
+	// val2's report is decoded but left out, as if its reveal did not match
+	require.NoError(t, emitRejectedVoteExtensions(ctx, 9, ci, decoded, decoded[:1]))
+
+	var expected sdk.Events
+	for _, rejected := range []*weightskeeper.EventVoteExtensionRejected{
+		{Validator: sdk.ConsAddress("val2").String(), Height: 9, Reason: "reveal does not match the previous commitment"},
+		{Validator: sdk.ConsAddress("val4").String(), Height: 9, Reason: "invalid vote extension"},
+	} {
+		event, err := sdk.TypedEventToEvent(rejected)
+		require.NoError(t, err)
+		expected = append(expected, event)
+	}
+	require.Equal(t, expected, ctx.EventManager().Events())
+}
+
+func TestMatchesLastCommit(t *testing.T) {
+	ci := abci.ExtendedCommitInfo{Round: 1, Votes: []abci.ExtendedVoteInfo{
+		extendedVote(t, "val1", 10, map[string]int64{"val1": 40}),
+		{Validator: abci.Validator{Address: []byte("val2"), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+	}}
+	lastCommit := abci.CommitInfo{Round: 1, Votes: []abci.VoteInfo{
+		{Validator: abci.Validator{Address: []byte("val1"), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagCommit},
+		{Validator: abci.Validator{Address: []byte("val2"), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+	}}
+	require.NoError(t, matchesLastCommit(ci, lastCommit))
+
+	// an absent vote can't be counted, nor a vote left out
+	ci.Votes[1].BlockIdFlag = cmtproto.BlockIDFlagCommit
+	require.Error(t, matchesLastCommit(ci, lastCommit))
+	ci.Votes = ci.Votes[:1]
+	require.Error(t, matchesLastCommit(ci, lastCommit))
+}
@@ -6,33 +6,34 @@ import (
 	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
 )
 
-// Provider defines an interface for interacting with the external data sources
+// Provider defines an interface for interacting with the external data sources. It reports the
+// data of the given validators keyed by their consensus address.
 type Provider struct {
 }
 
 // GetValidatorsUptime gets the validator percentage uptime based on the latest N blocks.
-func (receiver Provider) GetValidatorsUptime(ctx sdk.Context, gov govkeeper.Keeper) map[string]int64 {
+func (receiver Provider) GetValidatorsUptime(ctx sdk.Context, gov govkeeper.Keeper, validators []sdk.ConsAddress) map[string]int64 {
 	// mocked
-	uptimes := map[string]int64{}
-	uptimes["val1"] = 40
-	uptimes["val2"] = 10
-	return uptimes
+	return mockedValues(validators, 40, 10)
 }
 
 // GetValidatorsProposalsVotePercentage gets the percentage of all the voted proposals which a validator have voted on,
-func (receiver Provider) GetValidatorsProposalsVotePercentage(ctx sdk.Context, gov govkeeper.Keeper) map[string]int64 {
+func (receiver Provider) GetValidatorsProposalsVotePercentage(ctx sdk.Context, gov govkeeper.Keeper, validators []sdk.ConsAddress) map[string]int64 {
 	// mocked
-	proposals := map[string]int64{}
-	proposals["val1"] = 40
-	proposals["val2"] = 10
-	return proposals
+	return mockedValues(validators, 40, 10)
 }
 
 // GetValidatorsGitHubContributions gets the amount of contributions that a validator have made on the main repo,
-func (receiver Provider) GetValidatorsGitHubContributions(ctx sdk.Context) map[string]int64 {
+func (receiver Provider) GetValidatorsGitHubContributions(ctx sdk.Context, validators []sdk.ConsAddress) map[string]int64 {
 	// mocked
-	activity := map[string]int64{}
-	activity["val1"] = 2
-	activity["val2"] = 100
-	return activity
+	return mockedValues(validators, 2, 100)
+}
+
+// mockedValues hands the given values out to the validators in turn
+func mockedValues(validators []sdk.ConsAddress, values ...int64) map[string]int64 {
+	mocked := make(map[string]int64, len(validators))
+	for i, validator := range validators {
+		mocked[string(validator)] = values[i%len(values)]
+	}
+	return mocked
 }
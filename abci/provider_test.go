@@ -0,0 +1,136 @@
+package abci
+
+import (
+	"testing"
+
+	"cosmossdk.io/log"
+	storetypes "cosmossdk.io/store/types"
+	"github.com/ciprianmuja/weight-shift/mempool"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+)
+
+// reversingProvider is a tx provider that proposes the mempool txs in reverse order
+type reversingProvider struct {
+	calls int
+}
+
+func (p *reversingProvider) BuildProposal(_ sdk.Context, txs []sdk.Tx) ([]sdk.Tx, error) {
+	p.calls++
+	reversed := make([]sdk.Tx, 0, len(txs))
+	for i := len(txs) - 1; i >= 0; i-- {
+		reversed = append(reversed, txs[i])
+	}
+	return reversed, nil
+}
+
+// appendingProvider is a tx provider that adds a tx of its own to the proposal, like the bids
+// the ns provider adds
+type appendingProvider struct {
+	tx sdk.Tx
+}
+
+func (p *appendingProvider) BuildProposal(_ sdk.Context, txs []sdk.Tx) ([]sdk.Tx, error) {
+	return append(txs, p.tx), nil
+}
+
+func encodedTx(t *testing.T, txConfig client.TxConfig, memo string) []byte {
+	t.Helper()
+	builder := txConfig.NewTxBuilder()
+	builder.SetMemo(memo)
+	bz, err := txConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+	return bz
+}
+
+func txMemos(t *testing.T, txConfig client.TxConfig, rawTxs [][]byte) []string {
+	t.Helper()
+	var memos []string
+	for _, rawTx := range rawTxs {
+		tx, err := txConfig.TxDecoder()(rawTx)
+		require.NoError(t, err)
+		memos = append(memos, tx.(sdk.TxWithMemo).GetMemo())
+	}
+	return memos
+}
+
+func TestPrepareProposalTxProvider(t *testing.T) {
+	txConfig := testutils.MakeTestTxConfig()
+	ctx := testutil.DefaultContext(storetypes.NewKVStoreKey("ws"), storetypes.NewTransientStoreKey("transient_ws")).
+		WithConsensusParams(cmtproto.ConsensusParams{Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 100}})
+	req := &abci.RequestPrepareProposal{
+		Height:     1,
+		MaxTxBytes: 1 << 20,
+		Txs:        [][]byte{encodedTx(t, txConfig, "first"), encodedTx(t, txConfig, "second")},
+	}
+
+	// without a provider the mempool txs are proposed as they are
+	handler := NewPrepareProposalHandler(log.NewNopLogger(), weightskeeper.WeightsKeeper{}, nil, txConfig, nil)
+	res, err := handler.PrepareProposal()(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, req.Txs, res.Txs)
+
+	// with a provider the proposal is built by it
+	txProvider := &reversingProvider{}
+	handler = NewPrepareProposalHandler(log.NewNopLogger(), weightskeeper.WeightsKeeper{}, nil, txConfig, txProvider)
+	res, err = handler.PrepareProposal()(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, txProvider.calls)
+	require.Equal(t, []string{"second", "first"}, txMemos(t, txConfig, res.Txs))
+
+	// txs that no longer fit in the block are left out
+	req.MaxTxBytes = int64(len(req.Txs[1]))
+	res, err = handler.PrepareProposal()(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"second"}, txMemos(t, txConfig, res.Txs))
+}
+
+func TestPrepareProposalLaneBudgets(t *testing.T) {
+	txConfig := testutils.MakeTestEncodingConfig(bank.AppModuleBasic{}).TxConfig
+	ctx := testutil.DefaultContext(storetypes.NewKVStoreKey("ws"), storetypes.NewTransientStoreKey("transient_ws")).
+		WithConsensusParams(cmtproto.ConsensusParams{Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 100}})
+	signedTx := func(msg sdk.Msg, memo string) sdk.Tx {
+		builder := txConfig.NewTxBuilder()
+		require.NoError(t, builder.SetMsgs(msg))
+		builder.SetMemo(memo)
+		require.NoError(t, builder.SetSignatures(signing.SignatureV2{
+			PubKey: secp256k1.GenPrivKey().PubKey(),
+			Data:   &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+		}))
+		return builder.GetTx()
+	}
+	size := func(tx sdk.Tx) int64 {
+		bz, err := txConfig.TxEncoder()(tx)
+		require.NoError(t, err)
+		return int64(len(bz))
+	}
+
+	lanes, err := mempool.NewLaneMempoolFromConfig(mempool.Config{
+		PriorityMsgTypes:      []string{sdk.MsgTypeURL(&banktypes.MsgSend{})},
+		PriorityMaxBlockSpace: "0.25",
+	})
+	require.NoError(t, err)
+	priority := signedTx(&banktypes.MsgSend{}, "priority")
+	regular := signedTx(&banktypes.MsgMultiSend{}, "regular")
+	require.NoError(t, lanes.Insert(ctx, priority))
+	require.NoError(t, lanes.Insert(ctx, regular))
+
+	// the block has room for every tx, but the priority lane only for one of its txs
+	added := signedTx(&banktypes.MsgSend{}, "provider")
+	req := &abci.RequestPrepareProposal{Height: 1, MaxTxBytes: 4*size(priority) + 2*size(regular)}
+	handler := NewPrepareProposalHandler(log.NewNopLogger(), weightskeeper.WeightsKeeper{}, nil, txConfig, &appendingProvider{tx: added})
+	handler.SetLaneMempool(lanes)
+	res, err := handler.PrepareProposal()(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"priority", "regular"}, txMemos(t, txConfig, res.Txs))
+}
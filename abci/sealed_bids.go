@@ -0,0 +1,236 @@
+package abci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+/*
+	Sealed bids keep nameservice bids away from the proposer until it can no longer react to
+	them. A bidder first submits a tx whose memo commits to the hash of the bid. Once that tx is
+	included, the bidder hands the bid to the validators, which reveal it in their vote
+	extensions. The revealed bids are executed in the PreBlocker of the block that carries
+	those vote extensions, ahead of any tx of that block.
+
+	The bid is not encrypted: its privacy relies on the bidder only handing it out after the
+	commitment is included, and on validators not leaking it before they extend their vote.
+*/
+
+// SealedBidMemoPrefix prefixes the hex encoded commitment in the memo of a sealed bid
+// commitment tx
+const SealedBidMemoPrefix = "sealed-bid:"
+
+// SealedBid is a nameservice bid committed to in a tx memo before being revealed
+type SealedBid struct {
+	Name           string
+	Owner          string
+	ResolveAddress string
+	Amount         sdk.Coins
+	// Salt keeps the commitment of the bid from being guessed
+	Salt []byte
+}
+
+// Commitment returns the hash of the bid a bidder commits to
+func (b SealedBid) Commitment() ([]byte, error) {
+	bz, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(bz)
+	return hash[:], nil
+}
+
+// Msg returns the nameservice bid the sealed bid executes
+func (b SealedBid) Msg() *nstypes.MsgBid {
+	return &nstypes.MsgBid{
+		Name:           b.Name,
+		Owner:          b.Owner,
+		ResolveAddress: b.ResolveAddress,
+		Amount:         b.Amount,
+	}
+}
+
+// Validate performs a basic validation of the bid
+func (b SealedBid) Validate() error {
+	if b.Name == "" {
+		return errors.New("sealed bid without name")
+	}
+	if _, err := sdk.AccAddressFromBech32(b.Owner); err != nil {
+		return fmt.Errorf("invalid sealed bid owner: %w", err)
+	}
+	if !b.Amount.IsValid() || b.Amount.IsZero() {
+		return fmt.Errorf("invalid sealed bid amount: %s", b.Amount)
+	}
+	return nil
+}
+
+// SealedBidMemo returns the memo committing to the given bid commitment
+func SealedBidMemo(commitment []byte) string {
+	return SealedBidMemoPrefix + hex.EncodeToString(commitment)
+}
+
+// parseSealedBidMemo returns the commitment of a sealed bid memo
+func parseSealedBidMemo(memo string) ([]byte, bool) {
+	if !strings.HasPrefix(memo, SealedBidMemoPrefix) {
+		return nil, false
+	}
+	commitment, err := hex.DecodeString(strings.TrimPrefix(memo, SealedBidMemoPrefix))
+	if err != nil || len(commitment) != sha256.Size {
+		return nil, false
+	}
+	return commitment, true
+}
+
+// BidExecutor executes a revealed sealed bid
+type BidExecutor func(ctx sdk.Context, msg *nstypes.MsgBid) error
+
+// SealedBidPool holds the sealed bids handed to this node until their commitment is revealed
+// and executed
+type SealedBidPool struct {
+	mu   sync.Mutex
+	bids map[string]pooledBid
+}
+
+type pooledBid struct {
+	bid        SealedBid
+	commitment []byte
+	revealed   bool
+}
+
+func NewSealedBidPool() *SealedBidPool {
+	return &SealedBidPool{bids: make(map[string]pooledBid)}
+}
+
+// Submit adds a sealed bid to the pool, to be revealed once its commitment is included
+func (p *SealedBidPool) Submit(bid SealedBid) error {
+	if err := bid.Validate(); err != nil {
+		return err
+	}
+	commitment, err := bid.Commitment()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bids[string(commitment)] = pooledBid{bid: bid, commitment: commitment}
+	return nil
+}
+
+// Len returns the number of bids in the pool
+func (p *SealedBidPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.bids)
+}
+
+// reveals returns the pooled bids whose commitment is included in the committed state. Bids
+// revealed before whose commitment is gone, as it was executed or expired, leave the pool.
+func (p *SealedBidPool) reveals(ctx sdk.Context, keeper weightskeeper.WeightsKeeper) ([]SealedBid, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var reveals []SealedBid
+	for key, pooled := range p.bids {
+		committed, err := keeper.HasSealedBid(ctx, pooled.commitment, pooled.bid.Owner)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case committed:
+			pooled.revealed = true
+			p.bids[key] = pooled
+			reveals = append(reveals, pooled.bid)
+		case pooled.revealed:
+			delete(p.bids, key)
+		}
+	}
+	return reveals, nil
+}
+
+// verifySealedBids checks that every revealed bid of a vote extension has a commitment from its
+// owner in state
+func verifySealedBids(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, bids []SealedBid) error {
+	for _, bid := range bids {
+		commitment, err := bid.Commitment()
+		if err != nil {
+			return err
+		}
+		committed, err := keeper.HasSealedBid(ctx, commitment, bid.Owner)
+		if err != nil {
+			return err
+		}
+		if !committed {
+			return fmt.Errorf("sealed bid for %s by %s was not committed to", bid.Name, bid.Owner)
+		}
+	}
+	return nil
+}
+
+// processSealedBids executes the sealed bids revealed in the vote extensions of the last commit
+// and expires the commitments nobody revealed in time. The commitments of this block are
+// recorded by the SealedBidDecorator once their txs pass the ante handler.
+func (h *ProposalHandler) processSealedBids(ctx sdk.Context, ci abci.ExtendedCommitInfo) error {
+	params := h.keeper.GetParams(ctx)
+	if !params.SealedBids {
+		return nil
+	}
+
+	for _, report := range decodeReports(ci, h.logger) {
+		for _, bid := range report.voteExt.SealedBids {
+			if err := h.executeSealedBid(ctx, bid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return h.keeper.ExpireSealedBids(ctx, ctx.BlockHeight()-params.SealedBidTimeout)
+}
+
+// executeSealedBid executes a revealed bid once, if its owner committed to it. A failing bid
+// is dropped without affecting the block.
+func (h *ProposalHandler) executeSealedBid(ctx sdk.Context, bid SealedBid) error {
+	commitment, err := bid.Commitment()
+	if err != nil {
+		return err
+	}
+	committed, err := h.keeper.HasSealedBid(ctx, commitment, bid.Owner)
+	if err != nil || !committed {
+		// not committed to, or already executed after being revealed by another validator
+		return err
+	}
+	if err := h.keeper.RemoveSealedBid(ctx, commitment, bid.Owner); err != nil {
+		return err
+	}
+
+	executed := false
+	if h.bidExecutor != nil {
+		cacheCtx, write := ctx.CacheContext()
+		if err := h.bidExecutor(cacheCtx, bid.Msg()); err != nil {
+			h.logger.Error("failed to execute sealed bid", "err", err, "name", bid.Name, "owner", bid.Owner)
+		} else {
+			write()
+			executed = true
+		}
+	}
+
+	ctx.EventManager().EmitEvent(sdk.NewEvent(
+		weightskeeper.EventTypeSealedBid,
+		sdk.NewAttribute(weightskeeper.AttributeKeyName, bid.Name),
+		sdk.NewAttribute(weightskeeper.AttributeKeyBidder, bid.Owner),
+		sdk.NewAttribute(weightskeeper.AttributeKeyCommitment, hex.EncodeToString(commitment)),
+		sdk.NewAttribute(weightskeeper.AttributeKeyExecuted, strconv.FormatBool(executed)),
+	))
+	return nil
+}
@@ -0,0 +1,97 @@
+package abci
+
+import (
+	"time"
+
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/hashicorp/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	The ws metrics go through the SDK's telemetry package and are exposed on the node's
+	Prometheus endpoint when telemetry is enabled in app.toml. Latencies are recorded in a
+	histogram registered with the default Prometheus registry, which the endpoint gathers
+	along with the telemetry metrics, so they can be aggregated across nodes and queried by
+	quantile unlike the summaries of the SDK's sink.
+*/
+
+// metric keys of the ws module
+const (
+	metricKeyExtendVote      = "extend_vote"
+	metricKeyVerifyVote      = "verify_vote_extension"
+	metricKeyPrepareProposal = "prepare_proposal"
+	metricKeyProcessProposal = "process_proposal"
+	metricKeyRejected        = "vote_extensions_rejected"
+	metricKeyWeight          = "weight"
+	metricKeyEffectivePower  = "effective_power"
+
+	metricLabelHandler   = "handler"
+	metricLabelReason    = "reason"
+	metricLabelStage     = "stage"
+	metricLabelValidator = "validator"
+)
+
+// reasons a vote extension is rejected for, kept few to bound the counter's cardinality
+const (
+	rejectReasonSize       = "size"
+	rejectReasonDecode     = "decode"
+	rejectReasonHeight     = "height"
+	rejectReasonWeights    = "weights"
+	rejectReasonMetrics    = "metrics"
+	rejectReasonValidator  = "validator"
+	rejectReasonReveal     = "reveal"
+	rejectReasonSealedBids = "sealed_bids"
+)
+
+// stages a vote extension is rejected at: when verifying the vote, or when aggregating the
+// committed vote extensions
+const (
+	rejectStageVerify    = "verify"
+	rejectStageAggregate = "aggregate"
+)
+
+// handlerLatency is the latency of the ABCI++ handlers, in seconds, labelled by handler. The
+// buckets span the few milliseconds a handler takes up to the block time.
+var handlerLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: weight_shift.ModuleName,
+	Name:      "abci_handler_duration_seconds",
+	Help:      "Latency of the ws ABCI++ handlers",
+	Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+}, []string{metricLabelHandler})
+
+func init() {
+	prometheus.MustRegister(handlerLatency)
+}
+
+// measureSince records the latency of the given ABCI++ handler
+func measureSince(start time.Time, key string) {
+	handlerLatency.WithLabelValues(key).Observe(time.Since(start).Seconds())
+}
+
+// incrRejectedVoteExtensions counts a rejected vote extension
+func incrRejectedVoteExtensions(stage, reason string) {
+	telemetry.IncrCounterWithLabels([]string{weight_shift.ModuleName, metricKeyRejected}, 1, []metrics.Label{
+		telemetry.NewLabel(metricLabelStage, stage),
+		telemetry.NewLabel(metricLabelReason, reason),
+	})
+}
+
+// setWeightGauges records the weight applied to each validator and, for the validators in the
+// staking set, the consensus power the weight gives them
+func setWeightGauges(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, weights map[string]int64) {
+	for validator, weight := range weights {
+		consAddr := sdk.ConsAddress(validator)
+		labels := []metrics.Label{telemetry.NewLabel(metricLabelValidator, consAddr.String())}
+		telemetry.SetGaugeWithLabels([]string{weight_shift.ModuleName, metricKeyWeight}, float32(weight), labels)
+
+		power, err := keeper.EffectivePower(ctx, consAddr)
+		if err != nil {
+			continue
+		}
+		telemetry.SetGaugeWithLabels([]string{weight_shift.ModuleName, metricKeyEffectivePower}, float32(power), labels)
+	}
+}
@@ -0,0 +1,61 @@
+package abci
+
+import (
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
+	"github.com/hashicorp/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// inmemMetrics routes the global metrics to an in-memory sink for the duration of the test
+func inmemMetrics(t *testing.T) *metrics.InmemSink {
+	t.Helper()
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+	_, err := metrics.NewGlobal(cfg, sink)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = metrics.NewGlobal(cfg, &metrics.BlackholeSink{})
+	})
+	return sink
+}
+
+// handlerLatencyCount returns how many latencies of the handler were observed
+func handlerLatencyCount(t *testing.T, handler string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, handlerLatency.WithLabelValues(handler).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestVerifyVoteExtensionTelemetry(t *testing.T) {
+	sink := inmemMetrics(t)
+	ctx, keeper := setupKeeper(t, 1)
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	h := NewVoteExtensionHandler(log.NewNopLogger(), keeper, govkeeper.Keeper{})
+	verifications := handlerLatencyCount(t, metricKeyVerifyVote)
+
+	_, err := h.VerifyVoteExtensionHandler()(ctx, &abci.RequestVerifyVoteExtension{
+		Height:           5,
+		ValidatorAddress: []byte("val1"),
+		VoteExtension:    []byte("{"),
+	})
+	require.Error(t, err)
+	require.Empty(t, ctx.EventManager().Events())
+
+	data := sink.Data()
+	require.NotEmpty(t, data)
+	rejected, ok := data[0].Counters["ws.vote_extensions_rejected;stage=verify;reason=decode"]
+	require.True(t, ok)
+	require.Equal(t, 1, rejected.Count)
+	require.Equal(t, verifications+1, handlerLatencyCount(t, metricKeyVerifyVote))
+}
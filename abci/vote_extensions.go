@@ -9,6 +9,7 @@ import (
 	abci "github.com/cometbft/cometbft/abci/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
+	"time"
 )
 
 type VoteExtHandler struct {
@@ -16,6 +17,14 @@ type VoteExtHandler struct {
 	currentBlock int64               // current block height
 	provider     map[string]Provider // provider from which get the external weight data
 
+	// commit-reveal state: the weights this node committed to per height, and the commitments
+	// it saw from other validators per height
+	pendingReveals  map[int64]pendingReveal
+	seenCommitments map[int64]map[string][]byte
+
+	// sealedBids holds the sealed bids this node reveals, nil unless the app accepts them
+	sealedBids *SealedBidPool
+
 	Keeper    weightskeeper.WeightsKeeper
 	GovKeeper govkeeper.Keeper
 }
@@ -26,19 +35,49 @@ func NewVoteExtensionHandler(
 	govKeeper govkeeper.Keeper,
 ) *VoteExtHandler {
 	return &VoteExtHandler{
-		logger:    logger,
-		Keeper:    keeper,
-		GovKeeper: govKeeper,
+		logger:          logger,
+		pendingReveals:  make(map[int64]pendingReveal),
+		seenCommitments: make(map[int64]map[string][]byte),
+		Keeper:          keeper,
+		GovKeeper:       govKeeper,
 	}
 }
 
+// MaxVoteExtensionSize is the size above which a vote extension is rejected without being
+// decoded
+const MaxVoteExtensionSize = 64 * 1024
+
 // WeightedVotingPowerVoteExtension defines the canonical vote extension structure.
 type WeightedVotingPowerVoteExtension struct {
-	Weights map[string]int64
+	// Height is the height the vote was extended at, which keeps an extension from being
+	// replayed at a later height
+	Height int64
+	// Weights holds the weight reported for each validator, keyed by consensus address
+	Weights weightskeeper.ValidatorMap
+	// Metrics holds the raw metrics the weights were scored from, keyed by metric then validator
+	Metrics map[string]weightskeeper.ValidatorMap `json:",omitempty"`
+	// Salt and Commitment are only set in commit-reveal mode, where Weights and Salt reveal the
+	// commitment of the previous vote extension and Commitment commits to the next weights
+	Salt       []byte `json:",omitempty"`
+	Commitment []byte `json:",omitempty"`
+	// SealedBids reveals the sealed bids whose commitment is included in the committed state
+	SealedBids []SealedBid `json:",omitempty"`
+}
+
+// SetSealedBidPool sets the pool of sealed bids the node reveals in its vote extensions
+func (h *VoteExtHandler) SetSealedBidPool(pool *SealedBidPool) {
+	h.sealedBids = pool
 }
 
 func (h *VoteExtHandler) ExtendVoteHandler() sdk.ExtendVoteHandler {
 	return func(ctx sdk.Context, req *abci.RequestExtendVote) (*abci.ResponseExtendVote, error) {
+		defer measureSince(time.Now(), metricKeyExtendVote)
+
+		// before weighting is enabled the vote is extended with nothing
+		if !weightingEnabled(ctx, h.Keeper, req.Height) {
+			return &abci.ResponseExtendVote{VoteExtension: []byte{}}, nil
+		}
+
 		h.logger.Info(fmt.Sprintf("🗳️ :: Extending Vote"))
 		h.currentBlock = req.Height
 
@@ -46,48 +85,48 @@ func (h *VoteExtHandler) ExtendVoteHandler() sdk.ExtendVoteHandler {
 		if err != nil || computedWeights == nil {
 			computedWeights = make(map[string]int64)
 		}
-		var maxPercentage int64 = 55 // this could be set by a governance proposal
 
+		validators, err := h.Keeper.BondedValidators(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the bonded validators: %w", err)
+		}
 		provider := Provider{}
-		uptimePercentages := provider.GetValidatorsUptime(ctx, h.GovKeeper)
-		governancePercentages := provider.GetValidatorsUptime(ctx, h.GovKeeper)
-		githubContributions := provider.GetValidatorsGitHubContributions(ctx)
-		if uptimePercentages == nil || governancePercentages == nil || githubContributions == nil {
-			return nil, errors.New("invalid weights")
-		}
-		for validatorAddress, _ := range uptimePercentages {
-			computedWeights[validatorAddress] = governancePercentages[validatorAddress] + uptimePercentages[validatorAddress] +
-				githubContributions[validatorAddress]
-			// eventually add other params like from more sources
-		}
-
-		// Calculate the maximum and minimum values in the current computedWeights map
-		var maxWeight int64 = 0
-		var minWeight int64 = 0
-		for _, value := range computedWeights {
-			if value > maxWeight {
-				maxWeight = value
-			}
-			if value < minWeight {
-				minWeight = value
-			}
+		metrics := map[string]weightskeeper.ValidatorMap{
+			weightskeeper.MetricUptime:        provider.GetValidatorsUptime(ctx, h.GovKeeper, validators),
+			weightskeeper.MetricGovernance:    provider.GetValidatorsProposalsVotePercentage(ctx, h.GovKeeper, validators),
+			weightskeeper.MetricContributions: provider.GetValidatorsGitHubContributions(ctx, validators),
 		}
-
-		// Calculate the scaling factor to map values to the custom range
-		var scalingFactor int64 = 1 // fallback value, no changes
-		if maxWeight-minWeight > 0 {
-			scalingFactor = maxPercentage / (maxWeight - minWeight)
+		for _, values := range metrics {
+			if values == nil {
+				return nil, errors.New("invalid weights")
+			}
 		}
 
-		// Scale the computedWeights values to the custom range
-		for validatorAddress, _ := range uptimePercentages {
-			computedWeights[validatorAddress] = (computedWeights[validatorAddress] - minWeight) * scalingFactor
-			// Now, the values in computedWeights are scaled to the range [0, maxPercentage]
+		// the metrics are scored into weights in [0, MaxWeight], validators without metrics keep
+		// the weight they have
+		params := h.Keeper.GetParams(ctx)
+		scored, _ := weightskeeper.ScoreMetrics(metrics, params.MetricCoefficients)
+		for validatorAddress, weight := range scored {
+			computedWeights[validatorAddress] = weight
 		}
 
 		// produce a canonical vote extension
 		voteExt := WeightedVotingPowerVoteExtension{
 			Weights: computedWeights,
+			Metrics: metrics,
+		}
+		if params.CommitReveal {
+			voteExt, err = h.commitWeights(req.Height, computedWeights, metrics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to commit to weights: %w", err)
+			}
+		}
+		voteExt.Height = req.Height
+		if params.SealedBids && h.sealedBids != nil {
+			voteExt.SealedBids, err = h.sealedBids.reveals(ctx, h.Keeper)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reveal sealed bids: %w", err)
+			}
 		}
 
 		bz, err := json.Marshal(voteExt)
@@ -102,23 +141,160 @@ func (h *VoteExtHandler) ExtendVoteHandler() sdk.ExtendVoteHandler {
 
 func (h *VoteExtHandler) VerifyVoteExtensionHandler() sdk.VerifyVoteExtensionHandler {
 	return func(ctx sdk.Context, req *abci.RequestVerifyVoteExtension) (*abci.ResponseVerifyVoteExtension, error) {
-		h.logger.Info(fmt.Sprintf(" :: Verifying Extended Votes"))
-		var voteExt WeightedVotingPowerVoteExtension
+		defer measureSince(time.Now(), metricKeyVerifyVote)
 
-		err := json.Unmarshal(req.VoteExtension, &voteExt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal vote extension: %w", err)
+		// before weighting is enabled there is nothing to verify
+		if !weightingEnabled(ctx, h.Keeper, req.Height) {
+			return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_ACCEPT}, nil
 		}
 
-		// verify if they are valid
-		if err := h.verifyWeights(ctx, voteExt.Weights); err != nil {
-			return nil, fmt.Errorf("failed to verify weights from validator %X: %w", req.ValidatorAddress, err)
+		h.logger.Info(fmt.Sprintf(" :: Verifying Extended Votes"))
+
+		// CometBFT discards the events of VerifyVoteExtension, the rejection is only logged and
+		// counted here. Vote extensions left out of the aggregation are flagged with an event
+		// once the block is decided.
+		if reason, err := h.verifyVoteExtension(ctx, req); err != nil {
+			incrRejectedVoteExtensions(rejectStageVerify, reason)
+			h.logger.Error("rejecting vote extension", "validator", sdk.ConsAddress(req.ValidatorAddress).String(),
+				"height", req.Height, "reason", reason, "err", err)
+			return nil, err
 		}
 
 		return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_ACCEPT}, nil
 	}
 }
 
-func (h *VoteExtHandler) verifyWeights(ctx sdk.Context, weights map[string]int64) error {
+// verifyVoteExtension checks the vote extension a validator sent along with its vote, returning
+// the reason it is rejected for along with the error
+func (h *VoteExtHandler) verifyVoteExtension(ctx sdk.Context, req *abci.RequestVerifyVoteExtension) (string, error) {
+	voteExt, reason, err := decodeVoteExtension(req.VoteExtension, req.Height)
+	if err != nil {
+		return reason, fmt.Errorf("invalid vote extension from validator %X: %w", req.ValidatorAddress, err)
+	}
+
+	if err := verifyValidators(ctx, h.Keeper, voteExt); err != nil {
+		return rejectReasonValidator, fmt.Errorf("invalid vote extension from validator %X: %w", req.ValidatorAddress, err)
+	}
+
+	params := h.Keeper.GetParams(ctx)
+	if params.CommitReveal {
+		if err := h.verifyReveal(req.Height, req.ValidatorAddress, voteExt); err != nil {
+			return rejectReasonReveal, fmt.Errorf("failed to verify reveal from validator %X: %w", req.ValidatorAddress, err)
+		}
+	}
+	if len(voteExt.SealedBids) > 0 {
+		if !params.SealedBids {
+			return rejectReasonSealedBids, fmt.Errorf("validator %X revealed sealed bids while they are disabled", req.ValidatorAddress)
+		}
+		if err := verifySealedBids(ctx, h.Keeper, voteExt.SealedBids); err != nil {
+			return rejectReasonSealedBids, fmt.Errorf("failed to verify sealed bids from validator %X: %w", req.ValidatorAddress, err)
+		}
+	}
+	return "", nil
+}
+
+// decodeVoteExtension decodes a vote extension made at the given height and checks it
+// without looking at the state, returning the reason it is rejected for along with the error
+func decodeVoteExtension(bz []byte, height int64) (WeightedVotingPowerVoteExtension, string, error) {
+	var voteExt WeightedVotingPowerVoteExtension
+	if len(bz) > MaxVoteExtensionSize {
+		return voteExt, rejectReasonSize, fmt.Errorf("vote extension of %d bytes exceeds %d bytes", len(bz), MaxVoteExtensionSize)
+	}
+	if err := json.Unmarshal(bz, &voteExt); err != nil {
+		return voteExt, rejectReasonDecode, fmt.Errorf("failed to unmarshal vote extension: %w", err)
+	}
+	if voteExt.Height != height {
+		return voteExt, rejectReasonHeight, fmt.Errorf("vote extension made at height %d, expected %d", voteExt.Height, height)
+	}
+	if err := verifyWeights(voteExt.Weights); err != nil {
+		return voteExt, rejectReasonWeights, fmt.Errorf("failed to verify weights: %w", err)
+	}
+	if err := weightskeeper.ValidateMetrics(voteExt.Metrics); err != nil {
+		return voteExt, rejectReasonMetrics, fmt.Errorf("failed to verify metrics: %w", err)
+	}
+	return voteExt, "", nil
+}
+
+// verifyWeights checks that every reported weight is within [0, MaxWeight]
+func verifyWeights(weights map[string]int64) error {
+	for validator, weight := range weights {
+		if weight < 0 || weight > weightskeeper.MaxWeight {
+			return fmt.Errorf("weight %d of %X is out of range [0, %d]", weight, validator, weightskeeper.MaxWeight)
+		}
+	}
+	return nil
+}
+
+// verifyValidators checks that every validator a vote extension reports weights or metrics
+// for is known to staking by its consensus address, so that values reported under any other
+// key cannot silently drop out of the aggregation
+func verifyValidators(ctx sdk.Context, keeper weightskeeper.WeightsKeeper, voteExt WeightedVotingPowerVoteExtension) error {
+	reported := []map[string]int64{voteExt.Weights}
+	for _, values := range voteExt.Metrics {
+		reported = append(reported, values)
+	}
+	for _, values := range reported {
+		for validator := range values {
+			if _, err := keeper.GetValidatorByConsAddr(ctx, sdk.ConsAddress(validator)); err != nil {
+				return fmt.Errorf("unknown validator %X: %w", validator, err)
+			}
+		}
+	}
+	return nil
+}
+
+// commitWeights builds a commit-reveal vote extension, revealing the weights and metrics
+// committed to at the previous height and committing to the given ones. Extending a vote for the same height again, in a later round, reuses the commitment
+// already made for it.
+func (h *VoteExtHandler) commitWeights(height int64, weights map[string]int64, metrics map[string]weightskeeper.ValidatorMap) (WeightedVotingPowerVoteExtension, error) {
+	pending, ok := h.pendingReveals[height]
+	if !ok {
+		var err error
+		pending, err = newPendingReveal(weights, metrics)
+		if err != nil {
+			return WeightedVotingPowerVoteExtension{}, err
+		}
+		h.pendingReveals[height] = pending
+	}
+	for committed := range h.pendingReveals {
+		if committed < height-1 {
+			delete(h.pendingReveals, committed)
+		}
+	}
+
+	voteExt := WeightedVotingPowerVoteExtension{Commitment: pending.Commitment}
+	if previous, ok := h.pendingReveals[height-1]; ok {
+		voteExt.Weights = previous.Weights
+		voteExt.Metrics = previous.Metrics
+		voteExt.Salt = previous.Salt
+	}
+	return voteExt, nil
+}
+
+// verifyReveal checks the commitment of a vote extension and, if this node saw the validator's
+// commitment at the previous height, that the extension reveals it. A reveal this node cannot
+// check is accepted, as the node may have missed the commitment; PreBlocker ignores it
+// deterministically if it does not match.
+func (h *VoteExtHandler) verifyReveal(height int64, validator []byte, voteExt WeightedVotingPowerVoteExtension) error {
+	if err := validateCommitment(voteExt); err != nil {
+		return err
+	}
+
+	revealing := len(voteExt.Weights) > 0 || len(voteExt.Salt) > 0
+	if commitment, ok := h.seenCommitments[height-1][string(validator)]; ok && revealing {
+		if !revealMatches(commitment, voteExt) {
+			return errors.New("reveal does not match the previous commitment")
+		}
+	}
+
+	if h.seenCommitments[height] == nil {
+		h.seenCommitments[height] = make(map[string][]byte)
+	}
+	h.seenCommitments[height][string(validator)] = voteExt.Commitment
+	for seen := range h.seenCommitments {
+		if seen < height-1 {
+			delete(h.seenCommitments, seen)
+		}
+	}
 	return nil
 }
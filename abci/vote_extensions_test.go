@@ -0,0 +1,56 @@
+package abci
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeVoteExtension(t *testing.T) {
+	encode := func(voteExt WeightedVotingPowerVoteExtension) []byte {
+		bz, err := json.Marshal(voteExt)
+		require.NoError(t, err)
+		return bz
+	}
+
+	voteExt, reason, err := decodeVoteExtension(encode(WeightedVotingPowerVoteExtension{
+		Height:  5,
+		Weights: map[string]int64{"val1": 0, "val2": weightskeeper.MaxWeight},
+	}), 5)
+	require.NoError(t, err)
+	require.Empty(t, reason)
+	require.Equal(t, weightskeeper.MaxWeight, voteExt.Weights["val2"])
+
+	// validators are keyed by consensus address bytes, which are encoded in hex
+	consAddr := string([]byte{0xff, 0x00, 0x80})
+	bz := encode(WeightedVotingPowerVoteExtension{Height: 5, Weights: map[string]int64{consAddr: 12}})
+	require.Contains(t, string(bz), `"FF0080":12`)
+	voteExt, _, err = decodeVoteExtension(bz, 5)
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.ValidatorMap{consAddr: 12}, voteExt.Weights)
+
+	testCases := []struct {
+		name   string
+		bz     []byte
+		reason string
+	}{
+		{"omitted", nil, rejectReasonDecode},
+		{"undecodable", []byte("{"), rejectReasonDecode},
+		{"oversized", encode(WeightedVotingPowerVoteExtension{Height: 5, Salt: make([]byte, MaxVoteExtensionSize)}), rejectReasonSize},
+		{"replayed", encode(WeightedVotingPowerVoteExtension{Height: 4}), rejectReasonHeight},
+		{"validator not in hex", []byte(`{"Height":5,"Weights":{"val1":10}}`), rejectReasonDecode},
+		{"above max weight", encode(WeightedVotingPowerVoteExtension{Height: 5, Weights: map[string]int64{"val1": weightskeeper.MaxWeight + 1}}), rejectReasonWeights},
+		{"negative weight", encode(WeightedVotingPowerVoteExtension{Height: 5, Weights: map[string]int64{"val1": -1}}), rejectReasonWeights},
+		{"unknown metric", encode(WeightedVotingPowerVoteExtension{Height: 5, Metrics: map[string]weightskeeper.ValidatorMap{"stake": {"val1": 1}}}), rejectReasonMetrics},
+		{"negative metric", encode(WeightedVotingPowerVoteExtension{Height: 5, Metrics: map[string]weightskeeper.ValidatorMap{weightskeeper.MetricUptime: {"val1": -1}}}), rejectReasonMetrics},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, reason, err := decodeVoteExtension(tc.bz, 5)
+			require.Error(t, err)
+			require.Equal(t, tc.reason, reason)
+		})
+	}
+}
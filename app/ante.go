@@ -0,0 +1,43 @@
+package app
+
+import (
+	abci2 "github.com/ciprianmuja/weight-shift/abci"
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth/ante"
+)
+
+// setAnteHandler installs the default SDK ante handler, which checks fees, signatures and
+// sequences, wrapped with the weights specific decorators
+func (app *App) setAnteHandler(txConfig client.TxConfig) {
+	sdkAnteHandler, err := ante.NewAnteHandler(
+		ante.HandlerOptions{
+			AccountKeeper:   app.AccountKeeper,
+			BankKeeper:      app.BankKeeper,
+			SignModeHandler: txConfig.SignModeHandler(),
+			SigGasConsumer:  ante.DefaultSigVerificationGasConsumer,
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	app.SetAnteHandler(sdk.ChainAnteDecorators(
+		anteHandlerDecorator{handler: sdkAnteHandler},
+		// sealed bid commitments are only recorded for txs whose signatures were verified
+		abci2.NewSealedBidDecorator(app.WeightsKeeper),
+	))
+}
+
+// anteHandlerDecorator runs an ante handler as a decorator of a chain
+type anteHandlerDecorator struct {
+	handler sdk.AnteHandler
+}
+
+func (d anteHandlerDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, simulate bool, next sdk.AnteHandler) (sdk.Context, error) {
+	newCtx, err := d.handler(ctx, tx, simulate)
+	if err != nil {
+		return newCtx, err
+	}
+	return next(newCtx, tx, simulate)
+}
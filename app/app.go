@@ -9,12 +9,14 @@ import (
 	"fmt"
 	weight_shift "github.com/ciprianmuja/weight-shift"
 	abci2 "github.com/ciprianmuja/weight-shift/abci"
+	"github.com/ciprianmuja/weight-shift/mempool"
 	"github.com/ciprianmuja/weight-shift/provider"
+	apptypes "github.com/ciprianmuja/weight-shift/types"
 	"github.com/ciprianmuja/weight-shift/weightskeeper"
-	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/spf13/cast"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 
@@ -31,6 +33,8 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/params"
 	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 	paramproposal "github.com/cosmos/cosmos-sdk/x/params/types/proposal"
+	"github.com/cosmos/cosmos-sdk/x/slashing"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 
@@ -77,6 +81,7 @@ import (
 	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
 	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
 	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	slashingkeeper "github.com/cosmos/cosmos-sdk/x/slashing/keeper"
 	stakingkeeper "github.com/cosmos/cosmos-sdk/x/staking/keeper"
 	nskeeper "github.com/fatal-fruit/ns/keeper"
 	nameservice "github.com/fatal-fruit/ns/module"
@@ -116,6 +121,7 @@ type App struct {
 	AccountKeeper         authkeeper.AccountKeeper
 	BankKeeper            bankkeeper.Keeper
 	StakingKeeper         *stakingkeeper.Keeper
+	SlashingKeeper        slashingkeeper.Keeper
 	DistrKeeper           distrkeeper.Keeper
 	GovKeeper             govkeeper.Keeper
 	UpgradeKeeper         *upgradekeeper.Keeper
@@ -124,6 +130,13 @@ type App struct {
 	NameserviceKeeper     nskeeper.Keeper
 	WeightsKeeper         weightskeeper.WeightsKeeper
 
+	// txProvider is nil unless the node runs with --run-provider
+	txProvider provider.TxProvider
+	// SealedBidPool holds the sealed bids this node reveals in its vote extensions
+	SealedBidPool *abci2.SealedBidPool
+	// proposalHandler prepares and processes proposals and applies the injected weights
+	proposalHandler *abci2.ProposalHandler
+
 	mm           *module.Manager
 	BasicManager module.BasicManager
 
@@ -153,7 +166,7 @@ func NewApp(
 ) *App {
 	homePath := cast.ToString(appOpts.Get(flags.FlagHome))
 	// Set demo flag
-	//runProvider := cast.ToBool(appOpts.Get(apptypes.FlagRunProvider))
+	runProvider := cast.ToBool(appOpts.Get(apptypes.FlagRunProvider))
 
 	interfaceRegistry, _ := types.NewInterfaceRegistryWithOptions(types.InterfaceRegistryOptions{
 		ProtoFiles: proto.HybridResolver,
@@ -173,6 +186,16 @@ func NewApp(
 	std.RegisterLegacyAminoCodec(legacyAmino)
 	std.RegisterInterfaces(interfaceRegistry)
 
+	// the lane mempool replaces the default mempool when enabled in app.toml
+	var laneMempool *mempool.LaneMempool
+	if lanesCfg := mempool.ReadConfig(appOpts); lanesCfg.Enabled {
+		var err error
+		if laneMempool, err = mempool.NewLaneMempoolFromConfig(lanesCfg); err != nil {
+			panic(err)
+		}
+		baseAppOptions = append(baseAppOptions, baseapp.SetMempool(laneMempool))
+	}
+
 	bApp := baseapp.NewBaseApp(AppName, logger, db, txConfig.TxDecoder(), baseAppOptions...)
 	bApp.SetCommitMultiStoreTracer(traceStore)
 	bApp.SetVersion(version.Version)
@@ -182,6 +205,7 @@ func NewApp(
 		authtypes.StoreKey,
 		banktypes.StoreKey,
 		stakingtypes.StoreKey,
+		slashingtypes.StoreKey,
 		distrtypes.StoreKey,
 		govtypes.StoreKey,
 		paramstypes.StoreKey,
@@ -221,40 +245,6 @@ func NewApp(
 		authtypes.NewModuleAddress(govtypes.ModuleName).String(),
 	)
 
-	/*
-		*************************
-		Configure ABCI++ Handlers
-		*************************
-	*/
-	bp := &provider.LocalTxProvider{
-		Logger: logger,
-		Codec:  app.appCodec,
-		Signer: provider.LocalSigner{
-			KeyName:    valKeyName,
-			KeyringDir: homePath,
-		},
-		TxConfig:   app.txConfig,
-		AcctKeeper: app.AccountKeeper,
-	}
-	if err := bp.Init(); err != nil {
-		panic(err)
-	}
-
-	app.WeightsKeeper = weightskeeper.NewWeightsKeeper(
-		appCodec,
-		authcodec.NewBech32Codec(sdk.Bech32MainPrefix),
-		runtime.NewKVStoreService(keys[weight_shift.StoreKey]),
-	)
-
-	// set the PrepareProposal handler
-	voteExtHandler := abci2.NewVoteExtensionHandler(logger, app.WeightsKeeper, app.GovKeeper)
-	bApp.SetExtendVoteHandler(voteExtHandler.ExtendVoteHandler())
-	bApp.SetVerifyVoteExtensionHandler(voteExtHandler.VerifyVoteExtensionHandler())
-	prepareProposalHandler := abci2.NewPrepareProposalHandler(logger, app.WeightsKeeper, nil, nil)
-	bApp.SetPrepareProposal(prepareProposalHandler.PrepareProposal())
-	// set the ProcessProposal handler
-	bApp.SetProcessProposal(prepareProposalHandler.ProcessProposal())
-
 	app.ParamsKeeper = initParamsKeeper(appCodec, legacyAmino, keys[paramstypes.StoreKey], tkeys[paramstypes.TStoreKey])
 
 	// set the BaseApp's parameter store
@@ -292,6 +282,23 @@ func NewApp(
 		authcodec.NewBech32Codec(sdk.Bech32PrefixValAddr),
 		authcodec.NewBech32Codec(sdk.Bech32PrefixConsAddr),
 	)
+	app.SlashingKeeper = slashingkeeper.NewKeeper(
+		appCodec,
+		legacyAmino,
+		runtime.NewKVStoreService(keys[slashingtypes.StoreKey]),
+		app.StakingKeeper,
+		authtypes.NewModuleAddress(govtypes.ModuleName).String(),
+	)
+
+	app.WeightsKeeper = weightskeeper.NewWeightsKeeper(
+		appCodec,
+		authcodec.NewBech32Codec(sdk.Bech32MainPrefix),
+		runtime.NewKVStoreService(keys[weight_shift.StoreKey]),
+		app.GetSubspace(weight_shift.ModuleName),
+		app.StakingKeeper,
+		app.SlashingKeeper,
+	)
+
 	app.DistrKeeper = distrkeeper.NewKeeper(
 		appCodec,
 		runtime.NewKVStoreService(keys[distrtypes.StoreKey]),
@@ -302,6 +309,11 @@ func NewApp(
 		authtypes.NewModuleAddress(govtypes.ModuleName).String(),
 	)
 
+	// register the staking hooks, the ws weights follow validators joining and leaving the set
+	app.StakingKeeper.SetHooks(
+		stakingtypes.NewMultiStakingHooks(app.DistrKeeper.Hooks(), app.SlashingKeeper.Hooks(), app.WeightsKeeper),
+	)
+
 	app.UpgradeKeeper = upgradekeeper.NewKeeper(
 		skipUpgradeHeights,
 		runtime.NewKVStoreService(keys[upgradetypes.StoreKey]),
@@ -322,7 +334,8 @@ func NewApp(
 		runtime.NewKVStoreService(keys[govtypes.StoreKey]),
 		app.AccountKeeper,
 		app.BankKeeper,
-		app.StakingKeeper,
+		// the gov tally sees bonded tokens scaled by the ws weights when WeightedGovTally is on
+		weightskeeper.NewWeightedTallyStakingKeeper(app.StakingKeeper, app.WeightsKeeper),
 		app.DistrKeeper,
 		app.MsgServiceRouter(),
 		govConfig,
@@ -347,6 +360,59 @@ func NewApp(
 		DefaultDenom,
 	)
 
+	/*
+		*************************
+		Configure ABCI++ Handlers
+		*************************
+	*/
+	// the tx provider only runs on nodes that opted in with --run-provider
+	var txProvider provider.TxProvider
+	if runProvider {
+		providerCfg := provider.ReadConfig(appOpts)
+		signer, err := provider.NewSigner(providerCfg, valKeyName, homePath, provider.SignerOptions{
+			GasPrices: cast.ToString(appOpts.Get(server.FlagMinGasPrices)),
+			Simulate:  bApp.Simulate,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		bp := &provider.LocalTxProvider{
+			Logger:     logger,
+			Codec:      app.appCodec,
+			Signer:     signer,
+			TxConfig:   app.txConfig,
+			AcctKeeper: app.AccountKeeper,
+		}
+		if err := bp.Init(); err != nil {
+			panic(err)
+		}
+
+		if bp.Strategy, err = provider.NewStrategy(providerCfg.Strategy, bp); err != nil {
+			panic(err)
+		}
+		txProvider = bp
+	}
+	app.txProvider = txProvider
+
+	// set the PrepareProposal handler
+	voteExtHandler := abci2.NewVoteExtensionHandler(logger, app.WeightsKeeper, app.GovKeeper)
+	app.SealedBidPool = abci2.NewSealedBidPool()
+	voteExtHandler.SetSealedBidPool(app.SealedBidPool)
+	bApp.SetExtendVoteHandler(voteExtHandler.ExtendVoteHandler())
+	bApp.SetVerifyVoteExtensionHandler(app.withConsensusParams(voteExtHandler.VerifyVoteExtensionHandler()))
+	prepareProposalHandler := abci2.NewPrepareProposalHandler(logger, app.WeightsKeeper, app.StakingKeeper, app.txConfig, txProvider)
+	if laneMempool != nil {
+		prepareProposalHandler.SetLaneMempool(laneMempool)
+	}
+	prepareProposalHandler.SetBidExecutor(app.executeSealedBid)
+	app.proposalHandler = prepareProposalHandler
+	bApp.SetPrepareProposal(prepareProposalHandler.PrepareProposal())
+	// set the ProcessProposal handler
+	bApp.SetProcessProposal(prepareProposalHandler.ProcessProposal())
+	// set the PreBlocker, which applies the injected weights before the block's txs run
+	bApp.SetPreBlocker(prepareProposalHandler.PreBlocker)
+
 	app.mm = module.NewManager(
 		genutil.NewAppModule(
 			app.AccountKeeper, app.StakingKeeper, app,
@@ -357,10 +423,12 @@ func NewApp(
 		gov.NewAppModule(appCodec, &app.GovKeeper, app.AccountKeeper, app.BankKeeper, app.GetSubspace(govtypes.ModuleName)),
 		distribution.NewAppModule(appCodec, app.DistrKeeper, app.AccountKeeper, app.BankKeeper, app.StakingKeeper, app.GetSubspace(distrtypes.ModuleName)),
 		staking.NewAppModule(appCodec, app.StakingKeeper, app.AccountKeeper, app.BankKeeper, app.GetSubspace(stakingtypes.ModuleName)),
+		slashingModule{slashing.NewAppModule(appCodec, app.SlashingKeeper, app.AccountKeeper, app.BankKeeper, app.StakingKeeper, app.GetSubspace(slashingtypes.ModuleName), app.interfaceRegistry)},
 		upgrade.NewAppModule(app.UpgradeKeeper, app.AccountKeeper.AddressCodec()),
 		params.NewAppModule(app.ParamsKeeper),
 		consensus.NewAppModule(appCodec, app.ConsensusParamsKeeper),
 		nameservice.NewAppModule(appCodec, app.NameserviceKeeper),
+		weightskeeper.NewAppModule(app.WeightsKeeper),
 	)
 
 	// Basic manager
@@ -381,6 +449,7 @@ func NewApp(
 	app.mm.SetOrderBeginBlockers(
 		upgradetypes.ModuleName,
 		distrtypes.ModuleName,
+		slashingtypes.ModuleName,
 		stakingtypes.ModuleName,
 		genutiltypes.ModuleName,
 	)
@@ -396,8 +465,12 @@ func NewApp(
 		banktypes.ModuleName,
 		distrtypes.ModuleName,
 		stakingtypes.ModuleName,
+		slashingtypes.ModuleName,
 		govtypes.ModuleName,
 		genutiltypes.ModuleName,
+		// the validators bonded in genesis are known to the ws module once staking and genutil
+		// ran
+		weight_shift.ModuleName,
 		paramstypes.ModuleName,
 		upgradetypes.ModuleName,
 		consensusparamtypes.ModuleName,
@@ -407,6 +480,13 @@ func NewApp(
 	app.mm.SetOrderInitGenesis(genesisModuleOrder...)
 	app.mm.SetOrderExportGenesis(genesisModuleOrder...)
 
+	// the auth module is overridden to generate random genesis accounts
+	overrideModules := map[string]module.AppModuleSimulation{
+		authtypes.ModuleName: auth.NewAppModule(app.appCodec, app.AccountKeeper, randomGenesisAccounts, app.GetSubspace(authtypes.ModuleName)),
+	}
+	app.simulationManager = module.NewSimulationManagerFromAppModules(app.mm.Modules, overrideModules)
+	app.simulationManager.RegisterStoreDecoders()
+
 	app.configurator = module.NewConfigurator(
 		app.appCodec,
 		app.MsgServiceRouter(),
@@ -431,13 +511,15 @@ func NewApp(
 	app.MountKVStores(keys)
 	app.MountTransientStores(tkeys)
 
-	// <Upgrade handler setup here>
+	app.setUpgradeHandlers()
+	app.setUpgradeStoreLoader()
+
 	// initialize BaseApp
 	app.SetInitChainer(app.InitChainer)
 	app.SetBeginBlocker(app.BeginBlocker)
 	app.SetEndBlocker(app.EndBlocker)
 
-	// app.setAnteHandler(txConfig)
+	app.setAnteHandler(txConfig)
 
 	// At startup, after all modules have been registered, check that all prot
 	// annotations are correct.
@@ -468,7 +550,16 @@ func (app *App) Name() string { return app.BaseApp.Name() }
 
 // BeginBlocker application updates every begin block
 func (app *App) BeginBlocker(ctx sdk.Context) (sdk.BeginBlock, error) {
-	return app.mm.BeginBlock(ctx)
+	// the events the PreBlocker emitted on the block's context are otherwise not returned
+	// in the FinalizeBlock response
+	preBlockEvents := ctx.EventManager().ABCIEvents()
+
+	res, err := app.mm.BeginBlock(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.Events = append(preBlockEvents, res.Events...)
+	return res, nil
 }
 
 // EndBlocker application updates every end block
@@ -479,17 +570,10 @@ func (app *App) EndBlocker(ctx sdk.Context) (sdk.EndBlock, error) {
 // InitChainer application update at chain initialization
 func (app *App) InitChainer(ctx sdk.Context, req *abci.RequestInitChain) (*abci.ResponseInitChain, error) {
 	var genesisState GenesisState
-
-	// Enable VE
-	req.ConsensusParams = &cmtproto.ConsensusParams{
-		Abci: &cmtproto.ABCIParams{
-			VoteExtensionsEnableHeight: 2,
-		},
-	}
-
 	if err := json.Unmarshal(req.AppStateBytes, &genesisState); err != nil {
 		panic(err)
 	}
+
 	app.UpgradeKeeper.SetModuleVersionMap(ctx, app.mm.GetVersionMap())
 	return app.mm.InitGenesis(ctx, app.appCodec, genesisState)
 }
@@ -590,6 +674,9 @@ func (app *App) RegisterAPIRoutes(apiSvr *api.Server, apiConfig config.APIConfig
 	// Register grpc-gateway routes for all modules.
 	app.BasicManager.RegisterGRPCGatewayRoutes(clientCtx, apiSvr.GRPCGatewayRouter)
 
+	// Register the route bidders hand their sealed bids to.
+	apiSvr.Router.HandleFunc(SealedBidsRoute, app.submitSealedBid).Methods(http.MethodPost)
+
 	// register swagger API from root so that other applications can override easily
 	if err := server.RegisterSwaggerAPI(apiSvr.ClientCtx, apiSvr.Router, apiConfig.Swagger); err != nil {
 		panic(err)
@@ -625,6 +712,15 @@ func (app *App) GetBaseApp() *baseapp.BaseApp {
 	return app.BaseApp
 }
 
+// withConsensusParams sets the consensus params on the context the given handler runs with.
+// Unlike ExtendVote, BaseApp.VerifyVoteExtension leaves them unset, which would keep the
+// handler from seeing that weighting is enabled.
+func (app *App) withConsensusParams(handler sdk.VerifyVoteExtensionHandler) sdk.VerifyVoteExtensionHandler {
+	return func(ctx sdk.Context, req *abci.RequestVerifyVoteExtension) (*abci.ResponseVerifyVoteExtension, error) {
+		return handler(ctx.WithConsensusParams(app.GetConsensusParams(ctx)), req)
+	}
+}
+
 type EmptyAppOptions struct{}
 
 func (ao EmptyAppOptions) Get(_ string) interface{} {
@@ -635,6 +731,8 @@ func (ao EmptyAppOptions) Get(_ string) interface{} {
 func initParamsKeeper(appCodec codec.BinaryCodec, legacyAmino *codec.LegacyAmino, key, tkey storetypes.StoreKey) paramskeeper.Keeper {
 	paramsKeeper := paramskeeper.NewKeeper(appCodec, legacyAmino, key, tkey)
 
+	paramsKeeper.Subspace(weight_shift.ModuleName)
+
 	// TODO: ibc module subspaces can be removed after migration of params
 	// https://github.com/cosmos/ibc-go/issues/2010
 
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"testing"
+
+	"cosmossdk.io/log"
+	"github.com/ciprianmuja/weight-shift/mempool"
+	"github.com/ciprianmuja/weight-shift/provider"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	apptypes "github.com/ciprianmuja/weight-shift/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp(appOpts simtestutil.AppOptionsMap, baseAppOptions ...func(*baseapp.BaseApp)) *App {
+	return NewApp(log.NewNopLogger(), dbm.NewMemDB(), nil, true, map[int64]bool{}, "val1", appOpts, baseAppOptions...)
+}
+
+func TestRunProvider(t *testing.T) {
+	// the provider is off by default and needs no keyring
+	app := newTestApp(simtestutil.AppOptionsMap{})
+	require.Nil(t, app.txProvider)
+
+	app = newTestApp(simtestutil.AppOptionsMap{apptypes.FlagRunProvider: false, flags.FlagHome: t.TempDir()})
+	require.Nil(t, app.txProvider)
+
+	// opted in nodes build proposals with the provider
+	app = newTestApp(simtestutil.AppOptionsMap{apptypes.FlagRunProvider: true, flags.FlagHome: t.TempDir()})
+	require.NotNil(t, app.txProvider)
+
+	// the proposal strategy is read from app.toml
+	app = newTestApp(simtestutil.AppOptionsMap{
+		apptypes.FlagRunProvider: true, flags.FlagHome: t.TempDir(), provider.FlagStrategy: provider.StrategyFIFO,
+	})
+	require.NotNil(t, app.txProvider)
+	require.Panics(t, func() {
+		newTestApp(simtestutil.AppOptionsMap{
+			apptypes.FlagRunProvider: true, flags.FlagHome: t.TempDir(), provider.FlagStrategy: "unknown",
+		})
+	})
+
+	// provider txs can be signed by a remote signer instead of the node's keyring
+	server := testutils.NewMockRemoteSigner(map[string]cryptotypes.PrivKey{"val1": secp256k1.GenPrivKey()})
+	defer server.Close()
+	app = newTestApp(simtestutil.AppOptionsMap{
+		apptypes.FlagRunProvider: true, provider.FlagSigner: provider.SignerRemote, provider.FlagRemoteSignerURL: server.URL,
+	})
+	require.NotNil(t, app.txProvider)
+
+	// and fail fast when the keyring can't be set up
+	require.Panics(t, func() {
+		newTestApp(simtestutil.AppOptionsMap{apptypes.FlagRunProvider: "true"})
+	})
+}
+
+func TestLaneMempoolConfig(t *testing.T) {
+	require.NotPanics(t, func() {
+		newTestApp(simtestutil.AppOptionsMap{mempool.FlagEnabled: true})
+	})
+	require.Panics(t, func() {
+		newTestApp(simtestutil.AppOptionsMap{mempool.FlagEnabled: true, mempool.FlagPriorityMaxBlockSpace: "2"})
+	})
+}
+
+func TestProviderTxsPassAnte(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	providerAddr := sdk.AccAddress(priv.PubKey().Address())
+	node := newSingleNode(t, 0, providerAddr, sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 1000)))
+	node.nextBlock()
+
+	server := testutils.NewMockRemoteSigner(map[string]cryptotypes.PrivKey{"val1": priv})
+	defer server.Close()
+	signer := &provider.RemoteSigner{
+		URL:           server.URL,
+		KeyName:       "val1",
+		SignerOptions: provider.SignerOptions{Simulate: node.app.Simulate},
+	}
+	require.NoError(t, signer.Init(node.app.GetTxConfig(), node.app.AppCodec(), log.NewNopLogger()))
+
+	// the provider signs two bids for the same proposal, simulated against the committed state
+	ctx := node.ctx()
+	signer.ResetSequence()
+	acct, err := signer.RetreiveSigner(ctx, node.app.AccountKeeper)
+	require.NoError(t, err)
+	var txs [][]byte
+	for _, name := range []string{"alice.ns", "bob.ns"} {
+		tx := signer.BuildAndSignTx(ctx, acct, nstypes.MsgBid{
+			Name:           name,
+			Owner:          providerAddr.String(),
+			ResolveAddress: providerAddr.String(),
+			Amount:         sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 100)),
+		})
+		require.NotNil(t, tx, name)
+		bz, err := node.app.GetTxConfig().TxEncoder()(tx)
+		require.NoError(t, err)
+		txs = append(txs, bz)
+	}
+
+	// and both pass the ante handler of the block
+	node.nextBlock(txs...)
+	require.Len(t, node.txResults, 2)
+	for _, res := range node.txResults {
+		require.Zero(t, res.Code, res.Log)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	consensustypes "github.com/cosmos/cosmos-sdk/x/consensus/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenesisConsensusParams(t *testing.T) {
+	node := newSingleNode(t, 0, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+
+	// the consensus params are kept as given in genesis
+	node.nextBlock()
+	cp := node.params
+	require.Equal(t, *simtestutil.DefaultConsensusParams.Block, *cp.Block)
+	require.Equal(t, *simtestutil.DefaultConsensusParams.Evidence, *cp.Evidence)
+	require.Zero(t, cp.Abci.VoteExtensionsEnableHeight)
+
+	// and the ws params default when genesis has none
+	require.Equal(t, weightskeeper.DefaultParams(), node.app.WeightsKeeper.GetParams(node.ctx()))
+}
+
+func TestEnableVoteExtensionsByGovernance(t *testing.T) {
+	node := newSingleNode(t, 0, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+
+	// without vote extensions the handlers leave the blocks alone
+	node.nextBlock()
+	node.nextBlock()
+	require.Nil(t, node.lastCommit.Votes)
+
+	// a governance proposal enables vote extensions from height 5
+	cp := node.params
+	msg := &consensustypes.MsgUpdateParams{
+		Authority: authtypes.NewModuleAddress(govtypes.ModuleName).String(),
+		Block:     cp.Block,
+		Evidence:  cp.Evidence,
+		Validator: cp.Validator,
+		Abci:      &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 5},
+	}
+	handler := node.app.MsgServiceRouter().Handler(msg)
+	require.NotNil(t, handler)
+	_, err := handler(node.ctx(), msg)
+	require.NoError(t, err)
+
+	node.nextBlock()
+	node.nextBlock()
+	require.Nil(t, node.lastCommit.Votes)
+
+	// the weights reported from the enable height on are applied in the block after
+	require.NotEmpty(t, node.nextBlock())
+	require.False(t, hasEvent(node.events, proto.MessageName(&weightskeeper.EventWeightsUpdated{})))
+	node.nextBlock()
+	require.True(t, hasEvent(node.events, proto.MessageName(&weightskeeper.EventWeightsUpdated{})))
+}
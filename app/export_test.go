@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportZeroHeightGenesis(t *testing.T) {
+	node := newSingleNode(t, 2, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	for i := 0; i < 4; i++ {
+		node.nextBlock()
+	}
+
+	consAddr := sdk.ConsAddress(node.val.Address)
+	ctx := node.ctx()
+	validator, err := node.app.StakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	require.NoError(t, err)
+	require.NoError(t, node.app.SlashingKeeper.SetValidatorSigningInfo(ctx, consAddr,
+		slashingtypes.NewValidatorSigningInfo(consAddr, 3, 2, validator.UnbondingTime, false, 1)))
+	require.NoError(t, node.app.SlashingKeeper.SetMissedBlockBitmapValue(ctx, consAddr, 1, true))
+	require.NoError(t, node.app.WeightsKeeper.Penalties.Set(ctx, consAddr, 5))
+
+	exported, err := node.app.ExportAppStateAndValidators(true, nil, nil)
+	require.NoError(t, err)
+	require.Zero(t, exported.Height)
+
+	var genesis GenesisState
+	require.NoError(t, json.Unmarshal(exported.AppState, &genesis))
+
+	// the signing infos start over along with the missed blocks window
+	var slashingGenesis slashingtypes.GenesisState
+	node.app.AppCodec().MustUnmarshalJSON(genesis[slashingtypes.ModuleName], &slashingGenesis)
+	require.Len(t, slashingGenesis.SigningInfos, 1)
+	info := slashingGenesis.SigningInfos[0].ValidatorSigningInfo
+	require.Zero(t, info.StartHeight)
+	require.Zero(t, info.IndexOffset)
+	require.Zero(t, info.MissedBlocksCounter)
+	require.Empty(t, slashingGenesis.MissedBlocks[0].MissedBlocks)
+
+	// the ws weights are recorded as set at genesis, the penalties carry over
+	var wsGenesis weightskeeper.GenesisState
+	require.NoError(t, json.Unmarshal(genesis[weight_shift.ModuleName], &wsGenesis))
+	require.NotEmpty(t, wsGenesis.Weights)
+	for _, weight := range wsGenesis.Weights {
+		require.Zero(t, weight.Record.Height)
+		require.Zero(t, weight.Record.Epoch)
+	}
+	require.Equal(t, []weightskeeper.ValidatorValue{{Validator: consAddr.String(), Value: 5}}, wsGenesis.Penalties)
+
+	// the ws module is part of the default genesis and is exported on its own like any other
+	// module
+	require.Contains(t, node.app.DefaultGenesis(), weight_shift.ModuleName)
+	exportedWs, err := node.app.ExportAppStateAndValidators(false, nil, []string{weight_shift.ModuleName})
+	require.NoError(t, err)
+	var wsOnly GenesisState
+	require.NoError(t, json.Unmarshal(exportedWs.AppState, &wsOnly))
+	require.Len(t, wsOnly, 1)
+	require.Contains(t, wsOnly, weight_shift.ModuleName)
+
+	// a chain restarting from the export starts with the exported ws state
+	restarted := newTestApp(simtestutil.AppOptionsMap{})
+	_, err = restarted.InitChain(&abci.RequestInitChain{
+		ConsensusParams: &exported.ConsensusParams,
+		AppStateBytes:   exported.AppState,
+	})
+	require.NoError(t, err)
+	_, err = restarted.FinalizeBlock(&abci.RequestFinalizeBlock{Height: 1})
+	require.NoError(t, err)
+	_, err = restarted.Commit()
+	require.NoError(t, err)
+
+	restartedCtx := restarted.NewUncachedContext(false, cmtproto.Header{Height: 1})
+	weights, err := restarted.WeightsKeeper.GetWeights(restartedCtx)
+	require.NoError(t, err)
+	exportedWeights, err := node.app.WeightsKeeper.GetWeights(node.ctx())
+	require.NoError(t, err)
+	require.Equal(t, exportedWeights, weights)
+	penalty, err := restarted.WeightsKeeper.Penalties.Get(restartedCtx, consAddr)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), penalty)
+}
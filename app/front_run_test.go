@@ -0,0 +1,70 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+)
+
+// bidTx encodes a tx bidding for the name, front-run detection only looking at the msgs
+func bidTx(t *testing.T, app *App, name string, owner sdk.AccAddress, amount int64) []byte {
+	t.Helper()
+	txBuilder := app.GetTxConfig().NewTxBuilder()
+	require.NoError(t, txBuilder.SetMsgs(&nstypes.MsgBid{
+		Name:           name,
+		Owner:          owner.String(),
+		ResolveAddress: owner.String(),
+		Amount:         sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, amount)),
+	}))
+	bz, err := app.GetTxConfig().TxEncoder()(txBuilder.GetTx())
+	require.NoError(t, err)
+	return bz
+}
+
+func frontRunEvents(events []abci.Event) []map[string]string {
+	var frontRuns []map[string]string
+	for _, event := range events {
+		if event.Type != weightskeeper.EventTypeFrontRun {
+			continue
+		}
+		attributes := make(map[string]string)
+		for _, attr := range event.Attributes {
+			attributes[attr.Key] = attr.Value
+		}
+		frontRuns = append(frontRuns, attributes)
+	}
+	return frontRuns
+}
+
+func TestFrontRunEvents(t *testing.T) {
+	alice := sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address())
+	node := newSingleNode(t, 0, alice, nil)
+	node.nextBlock()
+
+	// the proposer bids from the account of its operator key
+	validator, err := node.app.StakingKeeper.GetValidatorByConsAddr(node.ctx(), sdk.ConsAddress(node.val.Address))
+	require.NoError(t, err)
+	valAddr, err := sdk.ValAddressFromBech32(validator.GetOperator())
+	require.NoError(t, err)
+	proposer := sdk.AccAddress(valAddr)
+
+	// front-runs are only flagged by default, the block is accepted and the flag is part of
+	// its events
+	node.nextBlock(bidTx(t, node.app, "alice.ns", proposer, 200), bidTx(t, node.app, "alice.ns", alice, 100))
+	require.Equal(t, []map[string]string{{
+		weightskeeper.AttributeKeyValidator:   validator.GetOperator(),
+		weightskeeper.AttributeKeyName:        "alice.ns",
+		weightskeeper.AttributeKeyFrontRunner: proposer.String(),
+		weightskeeper.AttributeKeyVictim:      alice.String(),
+		"mode":                                "BeginBlock",
+	}}, frontRunEvents(node.events))
+
+	// bids placed after the one of alice are fair game
+	node.nextBlock(bidTx(t, node.app, "alice.ns", alice, 100), bidTx(t, node.app, "alice.ns", proposer, 200))
+	require.Empty(t, frontRunEvents(node.events))
+}
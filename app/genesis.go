@@ -2,6 +2,9 @@ package app
 
 import (
 	"encoding/json"
+
+	"github.com/cosmos/cosmos-sdk/types/module"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 )
 
 // The genesis state of the blockchain is represented here as a map of raw json
@@ -12,3 +15,13 @@ import (
 // the ModuleBasicManager which populates json from each BasicModule
 // object provided to it during init.
 type GenesisState map[string]json.RawMessage
+
+// randomGenesisAccounts generates the genesis accounts of the simulations. Unlike the auth
+// module's generator it makes no vesting accounts, the app not including the vesting module.
+func randomGenesisAccounts(simState *module.SimulationState) authtypes.GenesisAccounts {
+	genesisAccs := make(authtypes.GenesisAccounts, len(simState.Accounts))
+	for i, acc := range simState.Accounts {
+		genesisAccs[i] = authtypes.NewBaseAccountWithAddress(acc.Address)
+	}
+	return genesisAccs
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func hasEvent(events []abci.Event, eventType string) bool {
+	for _, event := range events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPreBlockEvents(t *testing.T) {
+	node := newSingleNode(t, 2, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+
+	// the weights of the vote extensions made at height 2 are applied at height 3
+	node.nextBlock()
+	node.nextBlock()
+	require.False(t, hasEvent(node.events, proto.MessageName(&weightskeeper.EventWeightsUpdated{})))
+	node.nextBlock()
+	require.True(t, hasEvent(node.events, proto.MessageName(&weightskeeper.EventWeightsUpdated{})))
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogogateway"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainQuery(t *testing.T) {
+	node := newSingleNode(t, 2, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	for i := 0; i < 4; i++ {
+		node.nextBlock()
+	}
+
+	validators, err := node.app.StakingKeeper.GetBondedValidatorsByPower(node.ctx())
+	require.NoError(t, err)
+	validator := validators[0].GetOperator()
+	consAddr := sdk.ConsAddress(node.val.Address)
+
+	req := &weightskeeper.QueryExplainRequest{Validator: validator}
+	bz, err := req.Marshal()
+	require.NoError(t, err)
+	res, err := node.app.Query(node.ctx(), &abci.RequestQuery{Path: "/ws.v1.Query/Explain", Data: bz})
+	require.NoError(t, err)
+	require.Zero(t, res.Code, res.Log)
+
+	var explained weightskeeper.QueryExplainResponse
+	require.NoError(t, explained.Unmarshal(res.Value))
+	require.Equal(t, validator, explained.Validator)
+	require.Equal(t, consAddr.String(), explained.ConsensusAddress)
+	// the weights reported at height 3 were aggregated at height 4
+	require.Equal(t, int64(4), explained.Explanation.Height)
+	weight, err := node.app.WeightsKeeper.GetWeight(node.ctx(), consAddr)
+	require.NoError(t, err)
+	require.Equal(t, weight, explained.Explanation.Weight)
+	effectivePower, err := node.app.WeightsKeeper.EffectivePower(node.ctx(), consAddr)
+	require.NoError(t, err)
+	require.Equal(t, effectivePower, explained.EffectivePower)
+}
+
+func TestExplainGatewayRoute(t *testing.T) {
+	node := newSingleNode(t, 2, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	for i := 0; i < 4; i++ {
+		node.nextBlock()
+	}
+
+	validators, err := node.app.StakingKeeper.GetBondedValidatorsByPower(node.ctx())
+	require.NoError(t, err)
+	validator := validators[0].GetOperator()
+
+	// the route is served like the API server does, with the gogoproto JSON marshaler
+	queryHelper := baseapp.NewQueryServerTestHelper(node.ctx(), node.app.InterfaceRegistry())
+	weightskeeper.RegisterQueryServer(queryHelper, weightskeeper.NewQueryServer(node.app.WeightsKeeper))
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, &gateway.JSONPb{OrigName: true}))
+	require.NoError(t, weightskeeper.RegisterQueryHandlerClient(context.Background(), mux, weightskeeper.NewQueryClient(queryHelper)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws/v1/explain/"+validator, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var explained weightskeeper.QueryExplainResponse
+	require.NoError(t, node.app.AppCodec().UnmarshalJSON(rec.Body.Bytes(), &explained))
+	require.Equal(t, validator, explained.Validator)
+	require.Equal(t, int64(4), explained.Explanation.Height)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws/v1/explain/val1", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ReplayedWeight is the weight a validator ends up with after a replay, along with the share of
+// the total consensus power the weight gives it
+type ReplayedWeight struct {
+	Validator      string         `json:"validator"`
+	Weight         int64          `json:"weight"`
+	Power          int64          `json:"power"`
+	EffectivePower int64          `json:"effective_power"`
+	PowerShare     math.LegacyDec `json:"power_share"`
+}
+
+// BlockTxs returns the txs of the block at the given height
+type BlockTxs func(height int64) ([][]byte, error)
+
+// ReplayWeights replays the blocks from fromHeight to toHeight through the PreBlocker, on the
+// state committed at fromHeight-1 with candidate ws params: paramsJSON, when set, is decoded
+// over the params stored at fromHeight-1, so it only needs the params that change. The
+// weights are scored again from the metrics recorded in the vote extensions carried by the
+// blocks, then aggregated, penalized and capped as the chain would have with those params;
+// the weights the proposers injected are not used. The replay runs on a cache of the
+// committed state, nothing is written to the node's state.
+//
+// Only the ws pipeline is replayed: the blocks' txs are not executed, so the staking state
+// stays the one at fromHeight-1.
+func (app *App) ReplayWeights(paramsJSON []byte, fromHeight, toHeight int64, blockTxs BlockTxs) ([]ReplayedWeight, error) {
+	if fromHeight < 1 || toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid height range [%d, %d]", fromHeight, toHeight)
+	}
+
+	cms, err := app.CommitMultiStore().CacheMultiStoreWithVersion(fromHeight - 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the state at height %d: %w", fromHeight-1, err)
+	}
+	ctx := sdk.NewContext(cms, cmtproto.Header{ChainID: app.ChainID(), Height: fromHeight - 1}, false, app.Logger())
+	ctx = ctx.WithConsensusParams(app.GetConsensusParams(ctx))
+
+	params := app.WeightsKeeper.GetParams(ctx)
+	if len(paramsJSON) > 0 {
+		if err := json.Unmarshal(paramsJSON, &params); err != nil {
+			return nil, fmt.Errorf("failed to decode the candidate params: %w", err)
+		}
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid candidate params: %w", err)
+	}
+	app.WeightsKeeper.SetParams(ctx, params)
+
+	for height := fromHeight; height <= toHeight; height++ {
+		txs, err := blockTxs(height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the block at height %d: %w", height, err)
+		}
+		blockCtx := ctx.WithBlockHeight(height).WithEventManager(sdk.NewEventManager())
+		if _, err := app.proposalHandler.ReplayPreBlocker(blockCtx, &abci.RequestFinalizeBlock{Height: height, Txs: txs}); err != nil {
+			return nil, fmt.Errorf("failed to replay height %d: %w", height, err)
+		}
+	}
+
+	return app.weightedPowerShares(ctx)
+}
+
+// weightedPowerShares returns the weight of every bonded validator along with the share of the
+// total consensus power its weighted stake gives it, heaviest first
+func (app *App) weightedPowerShares(ctx sdk.Context) ([]ReplayedWeight, error) {
+	validators, err := app.StakingKeeper.GetBondedValidatorsByPower(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed := make([]ReplayedWeight, 0, len(validators))
+	var total int64
+	for _, val := range validators {
+		consAddr, err := val.GetConsAddr()
+		if err != nil {
+			return nil, err
+		}
+		weight, err := app.WeightsKeeper.GetWeight(ctx, consAddr)
+		if err != nil {
+			return nil, err
+		}
+		effectivePower, err := app.WeightsKeeper.EffectivePower(ctx, consAddr)
+		if err != nil {
+			return nil, err
+		}
+		total += effectivePower
+		replayed = append(replayed, ReplayedWeight{
+			Validator:      val.GetOperator(),
+			Weight:         weight,
+			Power:          val.ConsensusPower(sdk.DefaultPowerReduction),
+			EffectivePower: effectivePower,
+		})
+	}
+
+	for i := range replayed {
+		replayed[i].PowerShare = math.LegacyZeroDec()
+		if total > 0 {
+			replayed[i].PowerShare = math.LegacyNewDec(replayed[i].EffectivePower).QuoInt64(total)
+		}
+	}
+	sort.SliceStable(replayed, func(i, j int) bool {
+		return replayed[i].EffectivePower > replayed[j].EffectivePower
+	})
+	return replayed, nil
+}
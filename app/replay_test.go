@@ -0,0 +1,82 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	abci2 "github.com/ciprianmuja/weight-shift/abci"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayWeights(t *testing.T) {
+	node := newSingleNode(t, 2, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	for i := 0; i < 12; i++ {
+		node.nextBlock()
+	}
+
+	blockTxs := func(height int64) ([][]byte, error) {
+		txs, ok := node.blocks[height]
+		if !ok {
+			return nil, fmt.Errorf("block %d not found", height)
+		}
+		return txs, nil
+	}
+	committed, err := node.app.weightedPowerShares(node.ctx())
+	require.NoError(t, err)
+	require.NotEmpty(t, committed)
+	weights, err := node.app.WeightsKeeper.GetWeights(node.ctx())
+	require.NoError(t, err)
+
+	// with the stored params the replay ends where the chain did
+	replayed, err := node.app.ReplayWeights(nil, 2, node.height, blockTxs)
+	require.NoError(t, err)
+	require.Equal(t, committed, replayed)
+
+	// the weights are scored again from the recorded metrics, whatever the proposer injected
+	zeroedTxs := func(height int64) ([][]byte, error) {
+		txs, err := blockTxs(height)
+		if err != nil || len(txs) == 0 {
+			return txs, err
+		}
+		var injected abci2.WeightedVotingPower
+		require.NoError(t, json.Unmarshal(txs[0], &injected))
+		for validator := range injected.StakeWeightedWeighted {
+			injected.StakeWeightedWeighted[validator] = 0
+		}
+		bz, err := json.Marshal(injected)
+		require.NoError(t, err)
+		return append([][]byte{bz}, txs[1:]...), nil
+	}
+	replayed, err = node.app.ReplayWeights(nil, 2, node.height, zeroedTxs)
+	require.NoError(t, err)
+	require.Equal(t, committed, replayed)
+
+	// weighting enabled past the replayed heights leaves the weights of the starting state
+	replayed, err = node.app.ReplayWeights([]byte(`{"enable_height":100}`), 5, node.height, blockTxs)
+	require.NoError(t, err)
+	require.Len(t, replayed, len(committed))
+	for _, r := range replayed {
+		require.Equal(t, r.EffectivePower, r.Power*(100+r.Weight)/100)
+	}
+
+	_, err = node.app.ReplayWeights([]byte(`{"epoch_length":0}`), 2, node.height, blockTxs)
+	require.ErrorContains(t, err, "invalid candidate params")
+	_, err = node.app.ReplayWeights([]byte(`{"metric_coefficients":[{"metric":"uptime","coefficient":"0.5"}]}`), 2, node.height, blockTxs)
+	require.ErrorContains(t, err, "invalid candidate params")
+	_, err = node.app.ReplayWeights([]byte(`{"epoch_length":`), 2, node.height, blockTxs)
+	require.ErrorContains(t, err, "failed to decode the candidate params")
+	_, err = node.app.ReplayWeights(nil, node.height, 2, blockTxs)
+	require.ErrorContains(t, err, "invalid height range")
+	_, err = node.app.ReplayWeights(nil, 2, node.height+1, blockTxs)
+	require.ErrorContains(t, err, "block 13 not found")
+
+	// nothing the replays did reached the node's state
+	require.Equal(t, int64(12), node.app.LastBlockHeight())
+	after, err := node.app.WeightsKeeper.GetWeights(node.ctx())
+	require.NoError(t, err)
+	require.Equal(t, weights, after)
+	require.Zero(t, node.app.WeightsKeeper.GetParams(node.ctx()).EnableHeight)
+}
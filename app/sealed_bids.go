@@ -0,0 +1,38 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	abci2 "github.com/ciprianmuja/weight-shift/abci"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// SealedBidsRoute is the API route bidders hand their sealed bids to once the commitment is included
+const SealedBidsRoute = "/ws/sealed_bids"
+
+// executeSealedBid runs a revealed sealed bid through the nameservice msg handler
+func (app *App) executeSealedBid(ctx sdk.Context, msg *nstypes.MsgBid) error {
+	handler := app.MsgServiceRouter().Handler(msg)
+	if handler == nil {
+		return fmt.Errorf("no handler for %s", sdk.MsgTypeURL(msg))
+	}
+	_, err := handler(ctx, msg)
+	return err
+}
+
+// submitSealedBid adds the sealed bid posted as JSON to the pool of bids this node reveals
+func (app *App) submitSealedBid(w http.ResponseWriter, r *http.Request) {
+	var bid abci2.SealedBid
+	if err := json.NewDecoder(r.Body).Decode(&bid); err != nil {
+		http.Error(w, fmt.Sprintf("invalid sealed bid: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := app.SealedBidPool.Submit(bid); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,317 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	abci2 "github.com/ciprianmuja/weight-shift/abci"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtcrypto "github.com/cometbft/cometbft/crypto"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	clienttx "github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	protoio "github.com/cosmos/gogoproto/io"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+)
+
+// testChainID is the chain id of the single node chains
+const testChainID = "ws-test"
+
+// singleNode drives an App as the only validator of an in-process network, running every
+// height through the ABCI++ calls CometBFT makes
+type singleNode struct {
+	t   *testing.T
+	app *App
+	val *cmttypes.Validator
+	// privKey is the consensus key the vote extensions are signed with
+	privKey cmtcrypto.PrivKey
+	height  int64
+	// lastCommit carries the vote extension of the previous height into the next proposal
+	lastCommit abci.ExtendedCommitInfo
+	// events holds the block events of the last height
+	events []abci.Event
+	// txResults holds the results of the txs of the last height, the injected tx included
+	txResults []*abci.ExecTxResult
+	// params holds the consensus params as CometBFT tracks them, updated after every block
+	params cmtproto.ConsensusParams
+	// blocks holds the txs of every committed height, like the CometBFT block store
+	blocks map[int64][][]byte
+}
+
+// newSingleNode starts a chain whose genesis funds the given account and enables vote
+// extensions at the given height, zero leaving them disabled
+func newSingleNode(t *testing.T, voteExtensionsEnableHeight int64, account sdk.AccAddress, balance sdk.Coins) *singleNode {
+	t.Helper()
+
+	app := newTestApp(simtestutil.AppOptionsMap{}, baseapp.SetChainID(testChainID))
+	privKey := ed25519.GenPrivKey()
+	valSet := cmttypes.NewValidatorSet([]*cmttypes.Validator{cmttypes.NewValidator(privKey.PubKey(), 1)})
+
+	genAccs := []authtypes.GenesisAccount{authtypes.NewBaseAccount(account, nil, 0, 0)}
+	genesis, err := simtestutil.GenesisStateWithValSet(app.AppCodec(), app.DefaultGenesis(), valSet, genAccs,
+		banktypes.Balance{Address: account.String(), Coins: balance})
+	require.NoError(t, err)
+	appState, err := json.Marshal(genesis)
+	require.NoError(t, err)
+
+	consensusParams := *simtestutil.DefaultConsensusParams
+	consensusParams.Abci = &cmtproto.ABCIParams{VoteExtensionsEnableHeight: voteExtensionsEnableHeight}
+	_, err = app.InitChain(&abci.RequestInitChain{
+		ChainId:         testChainID,
+		ConsensusParams: &consensusParams,
+		AppStateBytes:   appState,
+	})
+	require.NoError(t, err)
+
+	return &singleNode{t: t, app: app, val: valSet.Validators[0], privKey: privKey, params: consensusParams,
+		blocks: make(map[int64][][]byte)}
+}
+
+// nextBlock proposes, votes on and commits the next height with the given txs, returning the
+// vote extension the node made for it
+func (n *singleNode) nextBlock(txs ...[]byte) []byte {
+	n.t.Helper()
+	n.height++
+
+	prepared, err := n.app.PrepareProposal(&abci.RequestPrepareProposal{
+		Height:          n.height,
+		Txs:             txs,
+		MaxTxBytes:      simtestutil.DefaultConsensusParams.Block.MaxBytes,
+		LocalLastCommit: n.lastCommit,
+		ProposerAddress: n.val.Address,
+	})
+	require.NoError(n.t, err)
+
+	lastCommit := abci.CommitInfo{Round: n.lastCommit.Round}
+	for _, vote := range n.lastCommit.Votes {
+		lastCommit.Votes = append(lastCommit.Votes, abci.VoteInfo{Validator: vote.Validator, BlockIdFlag: vote.BlockIdFlag})
+	}
+	processed, err := n.app.ProcessProposal(&abci.RequestProcessProposal{
+		Height:             n.height,
+		Txs:                prepared.Txs,
+		ProposedLastCommit: lastCommit,
+		ProposerAddress:    n.val.Address,
+	})
+	require.NoError(n.t, err)
+	require.Equal(n.t, abci.ResponseProcessProposal_ACCEPT, processed.Status)
+
+	// like CometBFT, only extend the vote once vote extensions are enabled
+	var voteExt []byte
+	if abciParams := n.params.Abci; abciParams != nil && abciParams.VoteExtensionsEnableHeight > 0 &&
+		n.height >= abciParams.VoteExtensionsEnableHeight {
+		extended, err := n.app.ExtendVote(context.Background(), &abci.RequestExtendVote{Height: n.height, Txs: prepared.Txs})
+		require.NoError(n.t, err)
+		voteExt = extended.VoteExtension
+
+		verified, err := n.app.VerifyVoteExtension(&abci.RequestVerifyVoteExtension{
+			Height:           n.height,
+			ValidatorAddress: n.val.Address,
+			VoteExtension:    voteExt,
+		})
+		require.NoError(n.t, err)
+		require.Equal(n.t, abci.ResponseVerifyVoteExtension_ACCEPT, verified.Status)
+	}
+
+	finalized, err := n.app.FinalizeBlock(&abci.RequestFinalizeBlock{
+		Height:          n.height,
+		Txs:             prepared.Txs,
+		ProposerAddress: n.val.Address,
+	})
+	require.NoError(n.t, err)
+	n.blocks[n.height] = prepared.Txs
+	n.events = finalized.Events
+	n.txResults = finalized.TxResults
+	if finalized.ConsensusParamUpdates != nil {
+		n.params = *finalized.ConsensusParamUpdates
+	}
+	_, err = n.app.Commit()
+	require.NoError(n.t, err)
+
+	n.lastCommit = abci.ExtendedCommitInfo{}
+	if voteExt != nil {
+		n.lastCommit.Votes = []abci.ExtendedVoteInfo{{
+			Validator:          abci.Validator{Address: n.val.Address, Power: n.val.VotingPower},
+			VoteExtension:      voteExt,
+			ExtensionSignature: n.signVoteExt(voteExt),
+			BlockIdFlag:        cmtproto.BlockIDFlagCommit,
+		}}
+	}
+	return voteExt
+}
+
+// signVoteExt signs a vote extension made at the current height the way CometBFT does, for
+// the chain id the app runs with
+func (n *singleNode) signVoteExt(voteExt []byte) []byte {
+	n.t.Helper()
+	var buf bytes.Buffer
+	err := protoio.NewDelimitedWriter(&buf).WriteMsg(&cmtproto.CanonicalVoteExtension{
+		Extension: voteExt,
+		Height:    n.height,
+		ChainId:   n.app.ChainID(),
+	})
+	require.NoError(n.t, err)
+	sig, err := n.privKey.Sign(buf.Bytes())
+	require.NoError(n.t, err)
+	return sig
+}
+
+func (n *singleNode) ctx() sdk.Context {
+	return n.app.NewUncachedContext(false, cmtproto.Header{ChainID: n.app.ChainID(), Height: n.height})
+}
+
+// signTx signs the given msgs with the key of an account in the committed state
+func (n *singleNode) signTx(priv cryptotypes.PrivKey, memo string, msgs ...sdk.Msg) []byte {
+	n.t.Helper()
+
+	txConfig := n.app.GetTxConfig()
+	addr := sdk.AccAddress(priv.PubKey().Address())
+	acc := n.app.AccountKeeper.GetAccount(n.ctx(), addr)
+	require.NotNil(n.t, acc)
+
+	txBuilder := txConfig.NewTxBuilder()
+	require.NoError(n.t, txBuilder.SetMsgs(msgs...))
+	txBuilder.SetMemo(memo)
+	txBuilder.SetGasLimit(200000)
+
+	signMode := signing.SignMode(txConfig.SignModeHandler().DefaultMode())
+	// the signer infos are part of the signed bytes, so they are set before signing
+	require.NoError(n.t, txBuilder.SetSignatures(signing.SignatureV2{
+		PubKey:   priv.PubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: acc.GetSequence(),
+	}))
+	sig, err := clienttx.SignWithPrivKey(context.Background(), signMode, authsigning.SignerData{
+		ChainID:       n.app.ChainID(),
+		Address:       addr.String(),
+		AccountNumber: acc.GetAccountNumber(),
+		Sequence:      acc.GetSequence(),
+		PubKey:        priv.PubKey(),
+	}, txBuilder, priv, txConfig, acc.GetSequence())
+	require.NoError(n.t, err)
+	require.NoError(n.t, txBuilder.SetSignatures(sig))
+
+	bz, err := txConfig.TxEncoder()(txBuilder.GetTx())
+	require.NoError(n.t, err)
+	return bz
+}
+
+func decodeVoteExt(t *testing.T, bz []byte) abci2.WeightedVotingPowerVoteExtension {
+	t.Helper()
+	var voteExt abci2.WeightedVotingPowerVoteExtension
+	require.NoError(t, json.Unmarshal(bz, &voteExt))
+	return voteExt
+}
+
+func TestSealedBids(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	bidder := sdk.AccAddress(priv.PubKey().Address())
+	coins := sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 1000))
+	node := newSingleNode(t, 2, bidder, coins)
+
+	var executed []*nstypes.MsgBid
+	node.app.proposalHandler.SetBidExecutor(func(_ sdk.Context, msg *nstypes.MsgBid) error {
+		executed = append(executed, msg)
+		return nil
+	})
+
+	node.nextBlock()
+	params := weightskeeper.DefaultParams()
+	params.SealedBids = true
+	node.app.WeightsKeeper.SetParams(node.ctx(), params)
+
+	bid := abci2.SealedBid{
+		Name:           "bob.cosmos",
+		Owner:          bidder.String(),
+		ResolveAddress: bidder.String(),
+		Amount:         sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 100)),
+		Salt:           []byte("salt"),
+	}
+	commitment, err := bid.Commitment()
+	require.NoError(t, err)
+
+	// the commitment tx only carries the hash of the bid
+	commitTx := node.signTx(priv, abci2.SealedBidMemo(commitment),
+		banktypes.NewMsgSend(bidder, bidder, sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 1))))
+
+	// the bid handed to the node before the commitment is included is not revealed
+	require.NoError(t, node.app.SealedBidPool.Submit(bid))
+	require.Empty(t, decodeVoteExt(t, node.nextBlock(commitTx)).SealedBids)
+	require.Zero(t, node.txResults[1].Code, node.txResults[1].Log)
+
+	has, err := node.app.WeightsKeeper.HasSealedBid(node.ctx(), commitment, bidder.String())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// once it is, the node reveals it in its vote extension and it runs in the next block
+	require.Equal(t, []abci2.SealedBid{bid}, decodeVoteExt(t, node.nextBlock()).SealedBids)
+	require.Empty(t, executed)
+
+	// until it runs it is revealed again, but it only runs once
+	require.Equal(t, []abci2.SealedBid{bid}, decodeVoteExt(t, node.nextBlock()).SealedBids)
+	require.Len(t, executed, 1)
+	require.Equal(t, bid.Msg(), executed[0])
+	require.True(t, hasEvent(node.events, weightskeeper.EventTypeSealedBid))
+
+	has, err = node.app.WeightsKeeper.HasSealedBid(node.ctx(), commitment, bidder.String())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// the executed bid leaves the pool and is not revealed again
+	require.Empty(t, decodeVoteExt(t, node.nextBlock()).SealedBids)
+	require.Zero(t, node.app.SealedBidPool.Len())
+	require.Len(t, executed, 1)
+}
+
+func TestSealedBidCommitmentRequiresSignature(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	bidder := sdk.AccAddress(priv.PubKey().Address())
+	node := newSingleNode(t, 2, bidder, sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 1000)))
+
+	node.nextBlock()
+	params := weightskeeper.DefaultParams()
+	params.SealedBids = true
+	node.app.WeightsKeeper.SetParams(node.ctx(), params)
+
+	commitment := make([]byte, 32)
+	msg := banktypes.NewMsgSend(bidder, bidder, sdk.NewCoins(sdk.NewInt64Coin(DefaultDenom, 1)))
+
+	// an unsigned tx can not commit on behalf of the bidder
+	txBuilder := node.app.GetTxConfig().NewTxBuilder()
+	require.NoError(t, txBuilder.SetMsgs(msg))
+	txBuilder.SetMemo(abci2.SealedBidMemo(commitment))
+	unsignedTx, err := node.app.GetTxConfig().TxEncoder()(txBuilder.GetTx())
+	require.NoError(t, err)
+
+	node.nextBlock(unsignedTx)
+	require.NotZero(t, node.txResults[1].Code)
+	has, err := node.app.WeightsKeeper.HasSealedBid(node.ctx(), commitment, bidder.String())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// a tx signed by someone else commits on behalf of its own signer only
+	other := secp256k1.GenPrivKey()
+	otherAddr := sdk.AccAddress(other.PubKey().Address())
+	node.app.AccountKeeper.SetAccount(node.ctx(), node.app.AccountKeeper.NewAccountWithAddress(node.ctx(), otherAddr))
+	node.nextBlock(node.signTx(other, abci2.SealedBidMemo(commitment),
+		banktypes.NewMsgSend(otherAddr, otherAddr, sdk.NewCoins())))
+	has, err = node.app.WeightsKeeper.HasSealedBid(node.ctx(), commitment, bidder.String())
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = node.app.WeightsKeeper.HasSealedBid(node.ctx(), commitment, otherAddr.String())
+	require.NoError(t, err)
+	require.True(t, has)
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+	simcli "github.com/cosmos/cosmos-sdk/x/simulation/client/cli"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+// simChainID is the chain id the simulations run on, which the ante handler checks the
+// signatures of the simulated txs against
+const simChainID = "simulation-app"
+
+// The simulations are skipped unless enabled, e.g.:
+//
+//	go test ./app -run TestFullAppSimulation -Enabled=true -NumBlocks=100 -BlockSize=200 -Commit=true -v
+func init() {
+	simcli.GetSimulatorFlags()
+}
+
+// fauxMerkleModeOpt speeds up the simulations by not building the IAVL merkle trees
+func fauxMerkleModeOpt(bapp *baseapp.BaseApp) {
+	bapp.SetFauxMerkleMode()
+}
+
+func newSimApp(logger log.Logger, db dbm.DB, baseAppOptions ...func(*baseapp.BaseApp)) *App {
+	appOptions := simtestutil.AppOptionsMap{
+		flags.FlagHome:            DefaultNodeHome,
+		server.FlagInvCheckPeriod: simcli.FlagPeriodValue,
+	}
+	baseAppOptions = append(baseAppOptions, baseapp.SetChainID(simChainID))
+	return NewApp(logger, db, nil, true, map[int64]bool{}, "val1", appOptions, baseAppOptions...)
+}
+
+// simulate runs the simulation configured by the flags on the given app
+func simulate(t *testing.T, app *App, config simtypes.Config) (simulation.Params, error) {
+	t.Helper()
+	_, simParams, err := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		simtestutil.AppStateFn(app.AppCodec(), app.SimulationManager(), app.DefaultGenesis()),
+		simtypes.RandomAccounts,
+		simtestutil.SimulationOperations(app, app.AppCodec(), config),
+		app.BlockedModuleAccountAddrs(app.ModuleAccountAddrs()),
+		config,
+		app.AppCodec(),
+	)
+	return simParams, err
+}
+
+func TestFullAppSimulation(t *testing.T) {
+	config := simcli.NewConfigFromFlags()
+	config.ChainID = simChainID
+
+	db, dir, logger, skip, err := simtestutil.SetupSimulation(config, "leveldb-app-sim", "Simulation",
+		simcli.FlagVerboseValue, simcli.FlagEnabledValue)
+	if skip {
+		t.Skip("skipping application simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+	defer func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	app := newSimApp(logger, db, fauxMerkleModeOpt)
+	simParams, simErr := simulate(t, app, config)
+
+	// export state and simParams before the simulation error is checked
+	require.NoError(t, simtestutil.CheckExportSimulation(app, config, simParams))
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simtestutil.PrintStats(db)
+	}
+}
+
+func TestAppImportExport(t *testing.T) {
+	config := simcli.NewConfigFromFlags()
+	config.ChainID = simChainID
+
+	db, dir, logger, skip, err := simtestutil.SetupSimulation(config, "leveldb-app-sim", "Simulation",
+		simcli.FlagVerboseValue, simcli.FlagEnabledValue)
+	if skip {
+		t.Skip("skipping application import/export simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+	defer func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	app := newSimApp(logger, db, fauxMerkleModeOpt)
+	simParams, simErr := simulate(t, app, config)
+	require.NoError(t, simtestutil.CheckExportSimulation(app, config, simParams))
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simtestutil.PrintStats(db)
+	}
+
+	t.Log("exporting genesis...")
+	exported, err := app.ExportAppStateAndValidators(false, []string{}, []string{})
+	require.NoError(t, err)
+
+	t.Log("importing genesis...")
+	newDB, newDir, _, _, err := simtestutil.SetupSimulation(config, "leveldb-app-sim-2", "Simulation-2",
+		simcli.FlagVerboseValue, simcli.FlagEnabledValue)
+	require.NoError(t, err, "simulation setup failed")
+	defer func() {
+		require.NoError(t, newDB.Close())
+		require.NoError(t, os.RemoveAll(newDir))
+	}()
+
+	newApp := newSimApp(log.NewNopLogger(), newDB, fauxMerkleModeOpt)
+
+	ctxA := app.NewContextLegacy(true, cmtproto.Header{Height: app.LastBlockHeight()})
+	ctxB := newApp.NewContextLegacy(true, cmtproto.Header{Height: app.LastBlockHeight()})
+	_, err = newApp.InitChainer(ctxB, &abci.RequestInitChain{AppStateBytes: exported.AppState})
+	if err != nil {
+		if strings.Contains(err.Error(), "validator set is empty after InitGenesis") {
+			t.Log("skipping simulation as all validators have been unbonded")
+			return
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, newApp.StoreConsensusParams(ctxB, exported.ConsensusParams))
+
+	t.Log("comparing stores...")
+	storeKeysPrefixes := []struct {
+		name     string
+		prefixes [][]byte
+	}{
+		{authtypes.StoreKey, [][]byte{}},
+		{stakingtypes.StoreKey, [][]byte{
+			stakingtypes.UnbondingQueueKey, stakingtypes.RedelegationQueueKey, stakingtypes.ValidatorQueueKey,
+			stakingtypes.HistoricalInfoKey, stakingtypes.UnbondingIDKey, stakingtypes.UnbondingIndexKey,
+			stakingtypes.UnbondingTypeKey, stakingtypes.ValidatorUpdatesKey,
+		}},
+		{slashingtypes.StoreKey, [][]byte{}},
+		{distrtypes.StoreKey, [][]byte{}},
+		{banktypes.StoreKey, [][]byte{banktypes.BalancesPrefix}},
+		{paramstypes.StoreKey, [][]byte{}},
+		{govtypes.StoreKey, [][]byte{}},
+		// the short lived commitments are not exported
+		{weight_shift.StoreKey, [][]byte{weight_shift.CommitmentsKey.Bytes(), weight_shift.SealedBidsKey.Bytes(), weight_shift.ExplanationsKey.Bytes()}},
+	}
+
+	for _, skp := range storeKeysPrefixes {
+		storeA := ctxA.KVStore(app.GetKey(skp.name))
+		storeB := ctxB.KVStore(newApp.GetKey(skp.name))
+
+		failedKVAs, failedKVBs := simtestutil.DiffKVStores(storeA, storeB, skp.prefixes)
+		require.Equal(t, len(failedKVAs), len(failedKVBs), "unequal sets of key-values to compare")
+
+		t.Logf("compared %d different key/value pairs between %s and %s\n", len(failedKVAs), app.GetKey(skp.name), newApp.GetKey(skp.name))
+		require.Len(t, failedKVAs, 0, simtestutil.GetSimulationLog(skp.name, app.SimulationManager().StoreDecoders, failedKVAs, failedKVBs))
+	}
+}
+
+func TestAppStateDeterminism(t *testing.T) {
+	if !simcli.FlagEnabledValue {
+		t.Skip("skipping application simulation")
+	}
+
+	config := simcli.NewConfigFromFlags()
+	config.InitialBlockHeight = 1
+	config.ExportParamsPath = ""
+	config.OnOperation = false
+	config.AllInvariants = false
+	config.ChainID = simChainID
+
+	numSeeds := 3
+	numTimesToRunPerSeed := 5
+	appHashList := make([]json.RawMessage, numTimesToRunPerSeed)
+
+	for i := 0; i < numSeeds; i++ {
+		config.Seed = rand.Int63()
+
+		for j := 0; j < numTimesToRunPerSeed; j++ {
+			var logger log.Logger
+			if simcli.FlagVerboseValue {
+				logger = log.NewTestLogger(t)
+			} else {
+				logger = log.NewNopLogger()
+			}
+
+			app := newSimApp(logger, dbm.NewMemDB(), interBlockCacheOpt())
+
+			fmt.Printf(
+				"running non-determinism simulation; seed %d: %d/%d, attempt: %d/%d\n",
+				config.Seed, i+1, numSeeds, j+1, numTimesToRunPerSeed,
+			)
+
+			_, err := simulate(t, app, config)
+			require.NoError(t, err)
+
+			appHashList[j] = app.LastCommitID().Hash
+			if j != 0 {
+				require.Equal(
+					t, string(appHashList[0]), string(appHashList[j]),
+					"non-determinism in seed %d: %d/%d, attempt: %d/%d\n", config.Seed, i+1, numSeeds, j+1, numTimesToRunPerSeed,
+				)
+			}
+		}
+	}
+}
+
+// interBlockCacheOpt runs the determinism simulations with the inter-block cache, as nodes do
+func interBlockCacheOpt() func(*baseapp.BaseApp) {
+	return baseapp.SetInterBlockCache(store.NewCommitKVStoreCacheManager())
+}
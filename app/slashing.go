@@ -0,0 +1,38 @@
+package app
+
+import (
+	"encoding/json"
+
+	"cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/x/slashing"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+)
+
+// slashingModule is the slashing module with downtime jailing switched off in its default
+// genesis. The app only runs x/slashing so the ws module can jail outliers (JailOutliers),
+// but the module's BeginBlocker also tracks the liveness of every validator and would jail
+// and slash the ones missing blocks, which chains running the app never did before. Its
+// default genesis is used by `init` as well as by the upgrade adding the module, so the
+// liveness tracking only jails once governance raises MinSignedPerWindow and
+// SlashFractionDowntime. Double signs are not slashed either way, the app does not include
+// the evidence module.
+type slashingModule struct {
+	slashing.AppModule
+}
+
+// DefaultGenesis returns the slashing genesis with downtime jailing switched off
+func (am slashingModule) DefaultGenesis(cdc codec.JSONCodec) json.RawMessage {
+	genesis := slashingtypes.DefaultGenesisState()
+	genesis.Params = DefaultSlashingParams()
+	return cdc.MustMarshalJSON(genesis)
+}
+
+// DefaultSlashingParams returns the default slashing params of the app: the ones of the
+// slashing module, without jailing or slashing validators for downtime
+func DefaultSlashingParams() slashingtypes.Params {
+	params := slashingtypes.DefaultParams()
+	params.MinSignedPerWindow = math.LegacyZeroDec()
+	params.SlashFractionDowntime = math.LegacyZeroDec()
+	return params
+}
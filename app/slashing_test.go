@@ -0,0 +1,48 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"cosmossdk.io/core/comet"
+	"cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDowntimeNotJailedByDefault(t *testing.T) {
+	node := newSingleNode(t, 0, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	node.nextBlock()
+	ctx, _ := node.ctx().CacheContext()
+
+	params, err := node.app.SlashingKeeper.GetParams(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultSlashingParams(), params)
+
+	// the validator misses every block of two signing windows
+	consAddr := sdk.ConsAddress(node.val.Address)
+	require.NoError(t, node.app.SlashingKeeper.SetValidatorSigningInfo(ctx, consAddr,
+		slashingtypes.NewValidatorSigningInfo(consAddr, 0, 0, time.Unix(0, 0), false, 0)))
+	for height := int64(1); height <= 2*params.SignedBlocksWindow; height++ {
+		err := node.app.SlashingKeeper.HandleValidatorSignature(ctx.WithBlockHeight(height), node.val.Address,
+			node.val.VotingPower, comet.BlockIDFlagAbsent)
+		require.NoError(t, err)
+	}
+
+	validator, err := node.app.StakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	require.NoError(t, err)
+	require.False(t, validator.IsJailed())
+	require.True(t, validator.IsBonded())
+
+	// governance opts in to downtime jailing by raising the slashing params
+	params.MinSignedPerWindow = math.LegacyNewDecWithPrec(5, 1)
+	require.NoError(t, node.app.SlashingKeeper.SetParams(ctx, params))
+	height := 2*params.SignedBlocksWindow + 1
+	require.NoError(t, node.app.SlashingKeeper.HandleValidatorSignature(ctx.WithBlockHeight(height), node.val.Address,
+		node.val.VotingPower, comet.BlockIDFlagAbsent))
+	validator, err = node.app.StakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	require.NoError(t, err)
+	require.True(t, validator.IsJailed())
+}
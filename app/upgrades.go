@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	"github.com/ciprianmuja/weight-shift/app/upgrades"
+	v2 "github.com/ciprianmuja/weight-shift/app/upgrades/v2"
+	v3 "github.com/ciprianmuja/weight-shift/app/upgrades/v3"
+)
+
+// Upgrades lists the upgrades the app knows how to run
+var Upgrades = []upgrades.Upgrade{
+	v2.Upgrade,
+	v3.Upgrade,
+}
+
+// setUpgradeHandlers registers the handler of every known upgrade with the upgrade keeper
+func (app *App) setUpgradeHandlers() {
+	for _, upgrade := range Upgrades {
+		app.UpgradeKeeper.SetUpgradeHandler(
+			upgrade.UpgradeName,
+			upgrade.CreateUpgradeHandler(app.mm, app.configurator),
+		)
+	}
+}
+
+// setUpgradeStoreLoader adds, renames and deletes the stores of the upgrade the chain halted
+// for, once the new binary loads the store at the upgrade height
+func (app *App) setUpgradeStoreLoader() {
+	upgradeInfo, err := app.UpgradeKeeper.ReadUpgradeInfoFromDisk()
+	if err != nil {
+		panic(fmt.Errorf("failed to read upgrade info from disk: %w", err))
+	}
+	if app.UpgradeKeeper.IsSkipHeight(upgradeInfo.Height) {
+		return
+	}
+
+	for _, upgrade := range Upgrades {
+		if upgradeInfo.Name == upgrade.UpgradeName {
+			storeUpgrades := upgrade.StoreUpgrades
+			app.SetStoreLoader(upgradetypes.UpgradeStoreLoader(upgradeInfo.Height, &storeUpgrades))
+		}
+	}
+}
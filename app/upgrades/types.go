@@ -0,0 +1,18 @@
+package upgrades
+
+import (
+	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+)
+
+// Upgrade is a named software upgrade: the handler run once the chain halts at the upgrade
+// height, and the stores added, renamed or deleted when the new binary starts
+type Upgrade struct {
+	// UpgradeName is the name of the governance upgrade plan the upgrade is run for
+	UpgradeName string
+	// CreateUpgradeHandler creates the handler migrating the state of the modules
+	CreateUpgradeHandler func(mm *module.Manager, configurator module.Configurator) upgradetypes.UpgradeHandler
+	// StoreUpgrades lists the stores the upgrade adds, renames or deletes
+	StoreUpgrades storetypes.StoreUpgrades
+}
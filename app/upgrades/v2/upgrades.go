@@ -0,0 +1,34 @@
+package v2
+
+import (
+	"context"
+
+	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/app/upgrades"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+)
+
+// UpgradeName is the name of the upgrade plan moving the ws weights to weight records
+const UpgradeName = "v2"
+
+var Upgrade = upgrades.Upgrade{
+	UpgradeName:          UpgradeName,
+	CreateUpgradeHandler: CreateUpgradeHandler,
+	// the slashing module jailing the ws outliers was not part of the app before v2
+	StoreUpgrades: storetypes.StoreUpgrades{
+		Added: []string{slashingtypes.StoreKey},
+	},
+}
+
+func CreateUpgradeHandler(mm *module.Manager, configurator module.Configurator) upgradetypes.UpgradeHandler {
+	return func(ctx context.Context, _ upgradetypes.Plan, fromVM module.VersionMap) (module.VersionMap, error) {
+		// the ws store was not versioned before, chains that ran it have the first layout
+		if _, ok := fromVM[weight_shift.ModuleName]; !ok {
+			fromVM[weight_shift.ModuleName] = 1
+		}
+		return mm.RunMigrations(ctx, configurator, fromVM)
+	}
+}
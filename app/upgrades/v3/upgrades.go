@@ -0,0 +1,23 @@
+package v3
+
+import (
+	"context"
+
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	"github.com/ciprianmuja/weight-shift/app/upgrades"
+	"github.com/cosmos/cosmos-sdk/types/module"
+)
+
+// UpgradeName is the name of the upgrade plan keying the ws state by consensus address
+const UpgradeName = "v3"
+
+var Upgrade = upgrades.Upgrade{
+	UpgradeName:          UpgradeName,
+	CreateUpgradeHandler: CreateUpgradeHandler,
+}
+
+func CreateUpgradeHandler(mm *module.Manager, configurator module.Configurator) upgradetypes.UpgradeHandler {
+	return func(ctx context.Context, _ upgradetypes.Plan, fromVM module.VersionMap) (module.VersionMap, error) {
+		return mm.RunMigrations(ctx, configurator, fromVM)
+	}
+}
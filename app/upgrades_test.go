@@ -0,0 +1,136 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cosmossdk.io/collections"
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/metrics"
+	"cosmossdk.io/store/rootmulti"
+	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	v2 "github.com/ciprianmuja/weight-shift/app/upgrades/v2"
+	v3 "github.com/ciprianmuja/weight-shift/app/upgrades/v3"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	consensusparamtypes "github.com/cosmos/cosmos-sdk/x/consensus/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeV2(t *testing.T) {
+	node := newSingleNode(t, 0, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	node.nextBlock()
+	ctx := node.ctx()
+
+	// a chain upgrading to v2 has weights in the first layout and no ws version
+	require.NoError(t, node.app.WeightsKeeper.Weights.Clear(ctx, nil))
+	legacyWeights := collections.NewMap(
+		collections.NewSchemaBuilder(runtime.NewKVStoreService(node.app.GetKey(weight_shift.StoreKey))),
+		weight_shift.WeightsKey, "weights", collections.StringKey, collections.Int64Value)
+	consAddr := sdk.ConsAddress(node.val.Address)
+	validator, err := node.app.StakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	require.NoError(t, err)
+	require.NoError(t, legacyWeights.Set(ctx, validator.GetOperator(), 30))
+	fromVM := node.app.mm.GetVersionMap()
+	delete(fromVM, weight_shift.ModuleName)
+
+	handler := v2.CreateUpgradeHandler(node.app.mm, node.app.configurator)
+	toVM, err := handler(ctx, upgradetypes.Plan{Name: v2.UpgradeName}, fromVM)
+	require.NoError(t, err)
+	require.Equal(t, uint64(weightskeeper.ConsensusVersion), toVM[weight_shift.ModuleName])
+
+	// the weight is moved to weight records, then keyed by consensus address
+	weight, err := node.app.WeightsKeeper.GetWeight(ctx, consAddr)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), weight)
+}
+
+func TestUpgradeV2StoreLoader(t *testing.T) {
+	// a chain upgrading to v2 has the stores of the baseline app, committed at the height
+	// before the upgrade
+	db := dbm.NewMemDB()
+	cms := rootmulti.NewStore(db, log.NewNopLogger(), metrics.NewNoOpMetrics())
+	for _, key := range []string{
+		authtypes.StoreKey, banktypes.StoreKey, stakingtypes.StoreKey, distrtypes.StoreKey, govtypes.StoreKey,
+		paramstypes.StoreKey, upgradetypes.StoreKey, consensusparamtypes.StoreKey, nstypes.StoreKey,
+		weight_shift.StoreKey,
+	} {
+		cms.MountStoreWithDB(storetypes.NewKVStoreKey(key), storetypes.StoreTypeIAVL, nil)
+	}
+	require.NoError(t, cms.LoadLatestVersion())
+	cms.Commit()
+
+	// the node halted for the upgrade, and restarts with the new binary
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "data"), 0o755))
+	bz, err := json.Marshal(upgradetypes.Plan{Name: v2.UpgradeName, Height: 2})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "data", upgradetypes.UpgradeInfoFilename), bz, 0o600))
+	app := NewApp(log.NewNopLogger(), db, nil, true, map[int64]bool{}, "val1",
+		simtestutil.AppOptionsMap{flags.FlagHome: home})
+	require.Equal(t, int64(1), app.LastBlockHeight())
+
+	// the upgrade starts the slashing module with downtime jailing off
+	ctx := app.NewUncachedContext(false, cmtproto.Header{Height: 2})
+	fromVM := app.mm.GetVersionMap()
+	delete(fromVM, slashingtypes.ModuleName)
+	delete(fromVM, weight_shift.ModuleName)
+	handler := v2.CreateUpgradeHandler(app.mm, app.configurator)
+	toVM, err := handler(ctx, upgradetypes.Plan{Name: v2.UpgradeName}, fromVM)
+	require.NoError(t, err)
+	require.Contains(t, toVM, slashingtypes.ModuleName)
+	params, err := app.SlashingKeeper.GetParams(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultSlashingParams(), params)
+}
+
+func TestUpgradeV3(t *testing.T) {
+	node := newSingleNode(t, 0, sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()), nil)
+	node.nextBlock()
+	ctx := node.ctx()
+
+	// a chain upgrading to v3 keys its ws state by operator address
+	consAddr := sdk.ConsAddress(node.val.Address)
+	validator, err := node.app.StakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	require.NoError(t, err)
+	storeService := runtime.NewKVStoreService(node.app.GetKey(weight_shift.StoreKey))
+	require.NoError(t, node.app.WeightsKeeper.Weights.Clear(ctx, nil))
+	legacyWeights := collections.NewMap(collections.NewSchemaBuilder(storeService),
+		weight_shift.WeightsKey, "weights", collections.StringKey, weightskeeper.WeightRecordValue)
+	require.NoError(t, legacyWeights.Set(ctx, validator.GetOperator(), weightskeeper.WeightRecord{Weight: 30, Height: 1}))
+	require.NoError(t, legacyWeights.Set(ctx, "val1", weightskeeper.WeightRecord{Weight: 40}))
+	fromVM := node.app.mm.GetVersionMap()
+	fromVM[weight_shift.ModuleName] = 2
+
+	handler := v3.CreateUpgradeHandler(node.app.mm, node.app.configurator)
+	toVM, err := handler(ctx, upgradetypes.Plan{Name: v3.UpgradeName}, fromVM)
+	require.NoError(t, err)
+	require.Equal(t, uint64(weightskeeper.ConsensusVersion), toVM[weight_shift.ModuleName])
+
+	// the weight of the validator is kept under its consensus address, the one of the unknown
+	// validator is dropped
+	record, err := node.app.WeightsKeeper.Weights.Get(ctx, consAddr)
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.WeightRecord{Weight: 30, Height: 1}, record)
+	weights, err := node.app.WeightsKeeper.GetWeights(ctx)
+	require.NoError(t, err)
+	require.Len(t, weights, 1)
+}
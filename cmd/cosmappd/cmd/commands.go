@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"errors"
+	"github.com/ciprianmuja/weight-shift/mempool"
+	"github.com/ciprianmuja/weight-shift/provider"
 	"github.com/ciprianmuja/weight-shift/testutils"
 	"github.com/ciprianmuja/weight-shift/types"
 	"io"
@@ -42,6 +44,9 @@ func initTendermintConfig() *tmcfg.Config {
 func initAppConfig() (string, interface{}) {
 	type CustomAppConfig struct {
 		serverconfig.Config
+
+		Provider provider.Config `mapstructure:"provider"`
+		Lanes    mempool.Config  `mapstructure:"lanes"`
 	}
 
 	srvCfg := serverconfig.DefaultConfig()
@@ -49,10 +54,12 @@ func initAppConfig() (string, interface{}) {
 	srvCfg.StateSync.SnapshotKeepRecent = 10
 
 	customAppConfig := CustomAppConfig{
-		Config: *srvCfg,
+		Config:   *srvCfg,
+		Provider: provider.DefaultConfig(),
+		Lanes:    mempool.DefaultConfig(),
 	}
 
-	defaultAppTemplate := serverconfig.DefaultConfigTemplate
+	defaultAppTemplate := serverconfig.DefaultConfigTemplate + provider.ConfigTemplate + mempool.ConfigTemplate
 
 	return defaultAppTemplate, customAppConfig
 }
@@ -76,6 +83,7 @@ func initRootCmd(rootCmd *cobra.Command, encodingConfig testutils.EncodingConfig
 		genesisCommand(encodingConfig, app.DefaultNodeHome, basicManager),
 		queryCommand(),
 		txCommand(),
+		wsCommand(),
 		keys.Commands(),
 	)
 
@@ -84,7 +92,7 @@ func initRootCmd(rootCmd *cobra.Command, encodingConfig testutils.EncodingConfig
 func addModuleInitFlags(startCmd *cobra.Command) {
 	crisis.AddModuleInitFlags(startCmd)
 	startCmd.Flags().String(types.FlagValKey, "", "Name of Validator Key to Sign Txs")
-	startCmd.Flags().String(types.FlagRunProvider, "false", "Run the transaction provider logic")
+	startCmd.Flags().Bool(types.FlagRunProvider, false, "Run the transaction provider logic")
 }
 
 func genesisCommand(encodingConfig testutils.EncodingConfig, defaultNodeHome string, basicManager module.BasicManager, cmds ...*cobra.Command) *cobra.Command {
@@ -113,6 +121,7 @@ func queryCommand() *cobra.Command {
 		server.QueryBlocksCmd(),
 		authcmd.QueryTxCmd(),
 		server.QueryBlockResultsCmd(),
+		wsQueryCommand(),
 	)
 
 	return cmd
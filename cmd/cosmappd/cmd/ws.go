@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"cosmossdk.io/log"
+	"github.com/ciprianmuja/weight-shift/app"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	tmcfg "github.com/cometbft/cometbft/config"
+	"github.com/cometbft/cometbft/store"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFromHeight = "from-height"
+	flagToHeight   = "to-height"
+	flagParams     = "params"
+)
+
+func wsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "ws",
+		Short:                      "Weight shift subcommands",
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		wsSimulateCommand(),
+	)
+
+	return cmd
+}
+
+func wsQueryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "ws",
+		Short:                      "Querying commands for the weight shift module",
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		wsExplainCommand(),
+	)
+
+	return cmd
+}
+
+func wsExplainCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain [validator-addr]",
+		Short: "Explain how the weight of a validator was derived",
+		Long: `Show how the weight of a validator was derived when the weights were last aggregated: the
+raw, normalized and weighted value of every metric, the reported weight, the grace period
+and penalty applied to it, and the effective power the resulting weight gives.`,
+		Example: fmt.Sprintf("%sd q ws explain cosmosvaloper1...", app.AppName),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := weightskeeper.NewQueryClient(clientCtx).Explain(cmd.Context(), &weightskeeper.QueryExplainRequest{Validator: args[0]})
+			if err != nil {
+				return err
+			}
+
+			if clientCtx.OutputFormat == flags.OutputFormatJSON {
+				bz, err := json.MarshalIndent(res, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(bz))
+				return nil
+			}
+
+			explanation := res.Explanation
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "validator:\t%s\n", res.Validator)
+			fmt.Fprintf(w, "consensus address:\t%s\n", res.ConsensusAddress)
+			fmt.Fprintf(w, "epoch:\t%d (height %d)\n", explanation.Epoch, explanation.Height)
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "METRIC\tRAW\tNORMALIZED\tCOEFFICIENT\tCONTRIBUTION")
+			for _, score := range explanation.Metrics {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", score.Metric, score.Raw, score.Normalized, score.Coefficient, score.Contribution)
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "metric weight:\t%d\n", explanation.MetricWeight)
+			fmt.Fprintf(w, "reported weight:\t%d\n", explanation.ReportedWeight)
+			for _, adjustment := range explanation.Adjustments {
+				fmt.Fprintf(w, "%s:\t%d -> %d\n", adjustment.Reason, adjustment.From, adjustment.To)
+			}
+			fmt.Fprintf(w, "weight:\t%d (%d%% bonus)\n", explanation.Weight, explanation.Weight)
+			fmt.Fprintf(w, "power:\t%d\n", res.Power)
+			fmt.Fprintf(w, "effective power:\t%d\n", res.EffectivePower)
+			return w.Flush()
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+func wsSimulateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Replay stored blocks through the weighting pipeline with candidate params",
+		Long: `Replay the blocks stored by the local node from --from-height to --to-height through the
+weighting pipeline, on the state committed at --from-height - 1, and print the weight and the
+share of the effective consensus power every bonded validator ends up with. The weights are
+scored again from the metrics recorded in the blocks' vote extensions, so candidate
+metric_coefficients apply to the replayed heights.
+
+The candidate params are read from the JSON file given with --params and decoded over the params
+stored at --from-height - 1, so the file only needs the params that change. Nothing is written to
+the node's state. The node must be stopped, the command opens its databases.`,
+		Example: fmt.Sprintf("%sd ws simulate --from-height 100 --to-height 200 --params params.json", app.AppName),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			cfg := serverCtx.Config
+
+			fromHeight, err := cmd.Flags().GetInt64(flagFromHeight)
+			if err != nil {
+				return err
+			}
+			toHeight, err := cmd.Flags().GetInt64(flagToHeight)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(flags.FlagOutput)
+			if err != nil {
+				return err
+			}
+			if output != flags.OutputFormatText && output != flags.OutputFormatJSON {
+				return fmt.Errorf("invalid output format %q", output)
+			}
+
+			var paramsJSON []byte
+			if paramsFile, _ := cmd.Flags().GetString(flagParams); paramsFile != "" {
+				if paramsJSON, err = os.ReadFile(paramsFile); err != nil {
+					return err
+				}
+			}
+
+			blockStoreDB, err := tmcfg.DefaultDBProvider(&tmcfg.DBContext{ID: "blockstore", Config: cfg})
+			if err != nil {
+				return err
+			}
+			blockStore := store.NewBlockStore(blockStoreDB)
+			defer blockStore.Close()
+			if toHeight > blockStore.Height() {
+				return fmt.Errorf("the node stored blocks up to height %d, cannot replay to %d", blockStore.Height(), toHeight)
+			}
+
+			db, err := dbm.NewDB("application", server.GetAppDBBackend(serverCtx.Viper), filepath.Join(cfg.RootDir, "data"))
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			replayApp := app.NewApp(log.NewNopLogger(), db, nil, true, map[int64]bool{}, "", serverCtx.Viper)
+
+			replayed, err := replayApp.ReplayWeights(paramsJSON, fromHeight, toHeight, func(height int64) ([][]byte, error) {
+				block := blockStore.LoadBlock(height)
+				if block == nil {
+					return nil, fmt.Errorf("block %d not found", height)
+				}
+				txs := make([][]byte, len(block.Data.Txs))
+				for i, tx := range block.Data.Txs {
+					txs[i] = tx
+				}
+				return txs, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if output == flags.OutputFormatJSON {
+				bz, err := json.MarshalIndent(replayed, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(bz))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VALIDATOR\tWEIGHT\tPOWER\tEFFECTIVE POWER\tSHARE")
+			for _, r := range replayed {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f%%\n", r.Validator, r.Weight, r.Power, r.EffectivePower, r.PowerShare.MustFloat64()*100)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().Int64(flagFromHeight, 1, "First height to replay")
+	cmd.Flags().Int64(flagToHeight, 0, "Last height to replay")
+	cmd.Flags().String(flagParams, "", "JSON file with the candidate ws params, decoded over the stored ones")
+	cmd.Flags().StringP(flags.FlagOutput, "o", flags.OutputFormatText, "Output format (text|json)")
+	_ = cmd.MarkFlagRequired(flagToHeight)
+
+	return cmd
+}
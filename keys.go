@@ -8,5 +8,12 @@ const (
 )
 
 var (
-	WeightsKey = collections.NewPrefix(0)
+	WeightsKey        = collections.NewPrefix(0)
+	BondingHeightsKey = collections.NewPrefix(1)
+	DeviationsKey     = collections.NewPrefix(2)
+	OutlierStreaksKey = collections.NewPrefix(3)
+	PenaltiesKey      = collections.NewPrefix(4)
+	CommitmentsKey    = collections.NewPrefix(5)
+	SealedBidsKey     = collections.NewPrefix(6)
+	ExplanationsKey   = collections.NewPrefix(7)
 )
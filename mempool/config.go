@@ -0,0 +1,94 @@
+package mempool
+
+import (
+	"fmt"
+
+	"cosmossdk.io/math"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/spf13/cast"
+)
+
+// Names of the lanes
+const (
+	LanePriority = "priority"
+	LaneDefault  = "default"
+)
+
+// Config is the [lanes] section of app.toml
+type Config struct {
+	// Enabled replaces the default mempool with the lane mempool
+	Enabled bool `mapstructure:"enabled"`
+	// PriorityMsgTypes are the type urls of the msgs going to the priority lane
+	PriorityMsgTypes []string `mapstructure:"priority-msg-types"`
+	// PriorityMaxBlockSpace is the share of a block the priority lane may fill
+	PriorityMaxBlockSpace string `mapstructure:"priority-max-block-space"`
+}
+
+// app.toml keys of the lanes config
+const (
+	FlagEnabled               = "lanes.enabled"
+	FlagPriorityMsgTypes      = "lanes.priority-msg-types"
+	FlagPriorityMaxBlockSpace = "lanes.priority-max-block-space"
+)
+
+// DefaultConfig returns the default lanes config, which gives ns bids a quarter of each block
+func DefaultConfig() Config {
+	return Config{
+		Enabled:               false,
+		PriorityMsgTypes:      []string{sdk.MsgTypeURL(&nstypes.MsgBid{})},
+		PriorityMaxBlockSpace: "0.25",
+	}
+}
+
+// ReadConfig reads the lanes config from the app options, using the defaults for the values
+// that are not set
+func ReadConfig(appOpts servertypes.AppOptions) Config {
+	cfg := DefaultConfig()
+	cfg.Enabled = cast.ToBool(appOpts.Get(FlagEnabled))
+	if v := cast.ToStringSlice(appOpts.Get(FlagPriorityMsgTypes)); len(v) > 0 {
+		cfg.PriorityMsgTypes = v
+	}
+	if v := cast.ToString(appOpts.Get(FlagPriorityMaxBlockSpace)); v != "" {
+		cfg.PriorityMaxBlockSpace = v
+	}
+	return cfg
+}
+
+// NewLaneMempoolFromConfig returns a mempool with a priority lane for the configured msg types
+// and a default lane for all other txs
+func NewLaneMempoolFromConfig(cfg Config) (*LaneMempool, error) {
+	maxBlockSpace, err := math.LegacyNewDecFromStr(cfg.PriorityMaxBlockSpace)
+	if err != nil {
+		return nil, err
+	}
+	if maxBlockSpace.IsNegative() || maxBlockSpace.GT(math.LegacyOneDec()) {
+		return nil, fmt.Errorf("priority max block space must be between 0 and 1: %s", maxBlockSpace)
+	}
+
+	return NewLaneMempool(
+		NewLane(LanePriority, maxBlockSpace, MatchMsgTypes(cfg.PriorityMsgTypes...)),
+		NewLane(LaneDefault, math.LegacyOneDec(), nil),
+	), nil
+}
+
+// ConfigTemplate is the app.toml template of the lanes config
+const ConfigTemplate = `
+###############################################################################
+###                           Mempool Lanes                                 ###
+###############################################################################
+
+[lanes]
+
+# Enabled replaces the default mempool with a mempool that has a priority lane
+# for the msg types below and a default lane for all other txs.
+enabled = {{ .Lanes.Enabled }}
+
+# Type urls of the msgs going to the priority lane.
+priority-msg-types = [{{ range .Lanes.PriorityMsgTypes }}"{{ . }}", {{ end }}]
+
+# Share of a block's tx bytes the priority lane may fill, the default lane
+# fills the rest.
+priority-max-block-space = "{{ .Lanes.PriorityMaxBlockSpace }}"
+`
@@ -0,0 +1,226 @@
+package mempool
+
+import (
+	"context"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkmempool "github.com/cosmos/cosmos-sdk/types/mempool"
+	"github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+/*
+	LaneMempool is an app-side mempool made of lanes. Every tx goes to the first lane matching
+	it, the last lane being the default lane which takes everything else. Proposals are filled
+	lane by lane, each lane taking at most its share of the block, so special txs such as ns
+	bids get block space of their own without being able to crowd out the others.
+*/
+
+var _ sdkmempool.Mempool = &LaneMempool{}
+
+// MatchFn reports whether a tx belongs to a lane
+type MatchFn func(tx sdk.Tx) bool
+
+// Lane is a part of the mempool holding the txs it matches
+type Lane struct {
+	Name string
+	// MaxBlockSpace is the share of a block's tx bytes the lane may fill
+	MaxBlockSpace math.LegacyDec
+	match         MatchFn
+	pool          sdkmempool.Mempool
+}
+
+// CountTx returns the number of txs in the lane
+func (l *Lane) CountTx() int {
+	return l.pool.CountTx()
+}
+
+// NewLane returns a lane keeping its txs in a priority nonce mempool
+func NewLane(name string, maxBlockSpace math.LegacyDec, match MatchFn) *Lane {
+	return &Lane{
+		Name:          name,
+		MaxBlockSpace: maxBlockSpace,
+		match:         match,
+		pool:          sdkmempool.DefaultPriorityMempool(),
+	}
+}
+
+// MatchMsgTypes matches the txs whose msgs all have one of the given type urls
+func MatchMsgTypes(msgTypes ...string) MatchFn {
+	types := make(map[string]bool, len(msgTypes))
+	for _, msgType := range msgTypes {
+		types[msgType] = true
+	}
+	return func(tx sdk.Tx) bool {
+		msgs := tx.GetMsgs()
+		if len(msgs) == 0 {
+			return false
+		}
+		for _, msg := range msgs {
+			if !types[sdk.MsgTypeURL(msg)] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type LaneMempool struct {
+	lanes []*Lane
+}
+
+// NewLaneMempool returns a mempool made of the given lanes in order of priority, the last one
+// being the default lane
+func NewLaneMempool(lanes ...*Lane) *LaneMempool {
+	return &LaneMempool{lanes: lanes}
+}
+
+// Lanes returns the lanes of the mempool in order of priority
+func (m *LaneMempool) Lanes() []*Lane {
+	return m.lanes
+}
+
+// laneFor returns the lane the tx belongs to
+func (m *LaneMempool) laneFor(tx sdk.Tx) *Lane {
+	for _, lane := range m.lanes[:len(m.lanes)-1] {
+		if lane.match(tx) {
+			return lane
+		}
+	}
+	return m.lanes[len(m.lanes)-1]
+}
+
+func (m *LaneMempool) Insert(ctx context.Context, tx sdk.Tx) error {
+	return m.laneFor(tx).pool.Insert(ctx, tx)
+}
+
+// Select iterates the txs of all lanes, lane by lane
+func (m *LaneMempool) Select(ctx context.Context, txs [][]byte) sdkmempool.Iterator {
+	return selectLanes(ctx, m.lanes, txs)
+}
+
+func (m *LaneMempool) CountTx() int {
+	var count int
+	for _, lane := range m.lanes {
+		count += lane.pool.CountTx()
+	}
+	return count
+}
+
+func (m *LaneMempool) Remove(tx sdk.Tx) error {
+	return m.laneFor(tx).pool.Remove(tx)
+}
+
+// Fill returns the encoded txs of a block of at most maxTxBytes, taking from each lane in turn
+// until it reaches its share of the block
+func (m *LaneMempool) Fill(ctx context.Context, txEncoder sdk.TxEncoder, maxTxBytes int64) ([][]byte, error) {
+	var txs [][]byte
+	space := m.newBlockSpace(maxTxBytes)
+	for _, lane := range m.lanes {
+		for it := lane.pool.Select(ctx, nil); it != nil && space.left(lane) > 0; it = it.Next() {
+			bz, err := txEncoder(it.Tx())
+			if err != nil {
+				return nil, err
+			}
+			if space.take(lane, it.Tx(), bz) {
+				txs = append(txs, bz)
+			}
+		}
+	}
+	return txs, nil
+}
+
+// Trim keeps, in order, the encoded txs that fit in the share of a block of at most maxTxBytes
+// of the lane they belong to. It holds to their share the proposals that were reworked after
+// being filled from the lanes.
+func (m *LaneMempool) Trim(txs [][]byte, txDecoder sdk.TxDecoder, maxTxBytes int64) ([][]byte, error) {
+	var trimmed [][]byte
+	space := m.newBlockSpace(maxTxBytes)
+	for _, bz := range txs {
+		tx, err := txDecoder(bz)
+		if err != nil {
+			return nil, err
+		}
+		if space.take(m.laneFor(tx), tx, bz) {
+			trimmed = append(trimmed, bz)
+		}
+	}
+	return trimmed, nil
+}
+
+// blockSpace tracks the bytes of a block left to each lane. A sender whose tx doesn't fit gets
+// no more txs in, as its later txs depend on it; the txs of other senders still may.
+type blockSpace struct {
+	total   int64
+	lanes   map[*Lane]int64
+	skipped map[string]bool
+}
+
+func (m *LaneMempool) newBlockSpace(maxTxBytes int64) *blockSpace {
+	space := &blockSpace{
+		total:   maxTxBytes,
+		lanes:   make(map[*Lane]int64, len(m.lanes)),
+		skipped: make(map[string]bool),
+	}
+	for _, lane := range m.lanes {
+		space.lanes[lane] = lane.MaxBlockSpace.MulInt64(maxTxBytes).TruncateInt64()
+	}
+	return space
+}
+
+// left returns the bytes the lane may still fill
+func (s *blockSpace) left(lane *Lane) int64 {
+	return min(s.lanes[lane], s.total)
+}
+
+// take reports whether the encoded tx fits in the lane, taking its bytes if so
+func (s *blockSpace) take(lane *Lane, tx sdk.Tx, bz []byte) bool {
+	sender := txSender(tx, bz)
+	if s.skipped[sender] {
+		return false
+	}
+	size := int64(len(bz))
+	if size > s.left(lane) {
+		s.skipped[sender] = true
+		return false
+	}
+	s.lanes[lane] -= size
+	s.total -= size
+	return true
+}
+
+// txSender returns the signer of the tx's first signature, the sender the mempool orders its
+// txs by nonce for. A tx without one stands for itself.
+func txSender(tx sdk.Tx, bz []byte) string {
+	if sigTx, ok := tx.(signing.SigVerifiableTx); ok {
+		sigs, err := sigTx.GetSignaturesV2()
+		if err == nil && len(sigs) > 0 && sigs[0].PubKey != nil {
+			return sdk.AccAddress(sigs[0].PubKey.Address()).String()
+		}
+	}
+	return string(bz)
+}
+
+// laneIterator iterates a lane and moves on to the next lanes once it is done
+type laneIterator struct {
+	sdkmempool.Iterator
+	ctx   context.Context
+	lanes []*Lane
+	txs   [][]byte
+}
+
+func selectLanes(ctx context.Context, lanes []*Lane, txs [][]byte) sdkmempool.Iterator {
+	for i, lane := range lanes {
+		if it := lane.pool.Select(ctx, txs); it != nil {
+			return &laneIterator{Iterator: it, ctx: ctx, lanes: lanes[i+1:], txs: txs}
+		}
+	}
+	return nil
+}
+
+func (it *laneIterator) Next() sdkmempool.Iterator {
+	if next := it.Iterator.Next(); next != nil {
+		return &laneIterator{Iterator: next, ctx: it.ctx, lanes: it.lanes, txs: it.txs}
+	}
+	return selectLanes(it.ctx, it.lanes, it.txs)
+}
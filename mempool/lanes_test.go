@@ -0,0 +1,144 @@
+package mempool_test
+
+import (
+	"strings"
+	"testing"
+
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	"github.com/ciprianmuja/weight-shift/mempool"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+)
+
+// signedTx returns a tx with the given msg and memo, signed for a new sender
+func signedTx(t *testing.T, txConfig client.TxConfig, msg sdk.Msg, memo string) sdk.Tx {
+	t.Helper()
+	return signedTxFrom(t, txConfig, secp256k1.GenPrivKey().PubKey(), 0, msg, memo)
+}
+
+// signedTxFrom returns a tx with the given msg and memo, signed by the given sender
+func signedTxFrom(t *testing.T, txConfig client.TxConfig, sender cryptotypes.PubKey, sequence uint64, msg sdk.Msg, memo string) sdk.Tx {
+	t.Helper()
+	builder := txConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(msg))
+	builder.SetMemo(memo)
+	require.NoError(t, builder.SetSignatures(signing.SignatureV2{
+		PubKey:   sender,
+		Data:     &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+		Sequence: sequence,
+	}))
+	return builder.GetTx()
+}
+
+func TestLaneMempool(t *testing.T) {
+	txConfig := testutils.MakeTestEncodingConfig(bank.AppModuleBasic{}).TxConfig
+	ctx := testutil.DefaultContext(storetypes.NewKVStoreKey("mempool"), storetypes.NewTransientStoreKey("transient_mempool"))
+
+	mp, err := mempool.NewLaneMempoolFromConfig(mempool.Config{
+		PriorityMsgTypes:      []string{sdk.MsgTypeURL(&banktypes.MsgSend{})},
+		PriorityMaxBlockSpace: "0.5",
+	})
+	require.NoError(t, err)
+
+	send := &banktypes.MsgSend{}
+	multiSend := &banktypes.MsgMultiSend{}
+	priority := []sdk.Tx{signedTx(t, txConfig, send, "priority-1"), signedTx(t, txConfig, send, "priority-2")}
+	regular := []sdk.Tx{signedTx(t, txConfig, multiSend, "regular-1"), signedTx(t, txConfig, multiSend, "regular-2")}
+	for _, tx := range append(regular, priority...) {
+		require.NoError(t, mp.Insert(ctx, tx))
+	}
+	require.Equal(t, 4, mp.CountTx())
+
+	// the priority lane comes first
+	var memos []string
+	for it := mp.Select(ctx, nil); it != nil; it = it.Next() {
+		memos = append(memos, it.Tx().(sdk.TxWithMemo).GetMemo())
+	}
+	require.ElementsMatch(t, []string{"priority-1", "priority-2"}, memos[:2])
+	require.ElementsMatch(t, []string{"regular-1", "regular-2"}, memos[2:])
+
+	// with room for one priority and two regular txs, the priority lane is held to half of the
+	// block and the default lane fills the rest
+	priorityBz, err := txConfig.TxEncoder()(priority[0])
+	require.NoError(t, err)
+	regularBz, err := txConfig.TxEncoder()(regular[0])
+	require.NoError(t, err)
+	filled, err := mp.Fill(ctx, txConfig.TxEncoder(), int64(len(priorityBz)+2*len(regularBz)))
+	require.NoError(t, err)
+	require.Len(t, filled, 3)
+	var priorityCount int
+	for _, rawTx := range filled {
+		tx, err := txConfig.TxDecoder()(rawTx)
+		require.NoError(t, err)
+		if _, ok := tx.GetMsgs()[0].(*banktypes.MsgSend); ok {
+			priorityCount++
+		}
+	}
+	require.Equal(t, 1, priorityCount)
+
+	// removed txs are taken out of their lane
+	require.NoError(t, mp.Remove(priority[0]))
+	require.NoError(t, mp.Remove(regular[0]))
+	require.Equal(t, 2, mp.CountTx())
+	require.Equal(t, 1, mp.Lanes()[0].CountTx())
+}
+
+func TestLaneMempoolSkipsSender(t *testing.T) {
+	txConfig := testutils.MakeTestEncodingConfig(bank.AppModuleBasic{}).TxConfig
+	mp, err := mempool.NewLaneMempoolFromConfig(mempool.Config{
+		PriorityMsgTypes:      []string{sdk.MsgTypeURL(&banktypes.MsgSend{})},
+		PriorityMaxBlockSpace: "0.5",
+	})
+	require.NoError(t, err)
+
+	encode := func(tx sdk.Tx) []byte {
+		bz, err := txConfig.TxEncoder()(tx)
+		require.NoError(t, err)
+		return bz
+	}
+	alice, bob := secp256k1.GenPrivKey().PubKey(), secp256k1.GenPrivKey().PubKey()
+	send := &banktypes.MsgSend{}
+	large := encode(signedTxFrom(t, txConfig, alice, 0, send, strings.Repeat("a", 200)))
+	small := encode(signedTxFrom(t, txConfig, bob, 0, send, "bob-1"))
+	later := encode(signedTxFrom(t, txConfig, alice, 1, send, "alice-2"))
+	regular := encode(signedTx(t, txConfig, &banktypes.MsgMultiSend{}, "regular"))
+
+	// the priority lane has room for the small txs but not for the large one: the sender of the
+	// large tx gets no more txs in, the others still do
+	maxTxBytes := 2 * int64(len(small)+len(later))
+	trimmed, err := mp.Trim([][]byte{large, small, later, regular}, txConfig.TxDecoder(), maxTxBytes)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{small, regular}, trimmed)
+
+	// filling the lanes skips the same way, the large tx being selected first
+	ctx := testutil.DefaultContext(storetypes.NewKVStoreKey("mempool"), storetypes.NewTransientStoreKey("transient_mempool"))
+	for priority, bz := range map[int64][]byte{3: large, 2: small, 1: regular} {
+		tx, err := txConfig.TxDecoder()(bz)
+		require.NoError(t, err)
+		require.NoError(t, mp.Insert(ctx.WithPriority(priority), tx))
+	}
+	filled, err := mp.Fill(ctx, txConfig.TxEncoder(), maxTxBytes)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{small, regular}, filled)
+}
+
+func TestLaneMempoolConfig(t *testing.T) {
+	_, err := mempool.NewLaneMempoolFromConfig(mempool.Config{PriorityMaxBlockSpace: "1.5"})
+	require.Error(t, err)
+	_, err = mempool.NewLaneMempoolFromConfig(mempool.Config{PriorityMaxBlockSpace: "half"})
+	require.Error(t, err)
+
+	mp, err := mempool.NewLaneMempoolFromConfig(mempool.DefaultConfig())
+	require.NoError(t, err)
+	require.Len(t, mp.Lanes(), 2)
+	require.Equal(t, math.LegacyNewDecWithPrec(25, 2), mp.Lanes()[0].MaxBlockSpace)
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/spf13/cast"
+)
+
+// Config is the [provider] section of app.toml
+type Config struct {
+	// Strategy is the name of the proposal strategy the tx provider runs
+	Strategy string `mapstructure:"strategy"`
+	// Signer selects whether provider txs are signed with a local keyring or a remote signer
+	Signer string `mapstructure:"signer"`
+	// KeyringBackend is the backend of the local keyring
+	KeyringBackend string `mapstructure:"keyring-backend"`
+	// RemoteSignerURL is the url of the remote signer
+	RemoteSignerURL string `mapstructure:"remote-signer-url"`
+}
+
+// app.toml keys of the tx provider config
+const (
+	FlagStrategy        = "provider.strategy"
+	FlagSigner          = "provider.signer"
+	FlagKeyringBackend  = "provider.keyring-backend"
+	FlagRemoteSignerURL = "provider.remote-signer-url"
+)
+
+// DefaultConfig returns the default tx provider config
+func DefaultConfig() Config {
+	return Config{
+		Strategy:       StrategyFrontRun,
+		Signer:         SignerLocal,
+		KeyringBackend: "test",
+	}
+}
+
+// ReadConfig reads the tx provider config from the app options, using the defaults for the
+// values that are not set
+func ReadConfig(appOpts servertypes.AppOptions) Config {
+	cfg := DefaultConfig()
+	if v := cast.ToString(appOpts.Get(FlagStrategy)); v != "" {
+		cfg.Strategy = v
+	}
+	if v := cast.ToString(appOpts.Get(FlagSigner)); v != "" {
+		cfg.Signer = v
+	}
+	if v := cast.ToString(appOpts.Get(FlagKeyringBackend)); v != "" {
+		cfg.KeyringBackend = v
+	}
+	cfg.RemoteSignerURL = cast.ToString(appOpts.Get(FlagRemoteSignerURL))
+	return cfg
+}
+
+// ConfigTemplate is the app.toml template of the tx provider config
+const ConfigTemplate = `
+###############################################################################
+###                           Tx Provider                                   ###
+###############################################################################
+
+[provider]
+
+# Strategy used to build proposals when the node runs with --run-provider.
+# One of passthrough, fee-priority, front-run or fifo.
+strategy = "{{ .Provider.Strategy }}"
+
+# Signer of the provider txs, local to use a keyring on the node or remote to
+# use a remote signer.
+signer = "{{ .Provider.Signer }}"
+
+# Backend of the local keyring, one of test, file or os.
+keyring-backend = "{{ .Provider.KeyringBackend }}"
+
+# URL of the remote signer.
+remote-signer-url = "{{ .Provider.RemoteSignerURL }}"
+`
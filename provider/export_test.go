@@ -0,0 +1,16 @@
+package provider
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// SignMsgs signs a tx carrying any msgs, as ns bids can't be signed without the ns codec
+func (ls *LocalSigner) SignMsgs(ctx sdk.Context, acct types.AccountI, msgs ...sdk.Msg) sdk.Tx {
+	return ls.buildAndSignTx(ctx, acct, msgs...)
+}
+
+// SignMsgs signs a tx carrying any msgs, as ns bids can't be signed without the ns codec
+func (rs *RemoteSigner) SignMsgs(ctx sdk.Context, acct types.AccountI, msgs ...sdk.Msg) sdk.Tx {
+	return rs.buildAndSignTx(ctx, acct, msgs...)
+}
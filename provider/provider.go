@@ -5,7 +5,6 @@ import (
 	"cosmossdk.io/math"
 	"fmt"
 	"github.com/cosmos/cosmos-sdk/client"
-	"github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/codec/address"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
@@ -14,6 +13,7 @@ import (
 	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
 	"github.com/cosmos/cosmos-sdk/x/auth/types"
 	nstypes "github.com/fatal-fruit/ns/types"
+	"os"
 )
 
 /*
@@ -28,26 +28,36 @@ import (
 	special transactions of this nature.
 */
 
+// TxProvider reorders or extends the transactions of a proposal before it is broadcast. It is
+// only run on nodes that opted in with the --run-provider flag.
 type TxProvider interface {
 	BuildProposal(ctx sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error)
-	getMatchingBid(ctx sdk.Context, bid *nstypes.MsgBid) sdk.Tx
 }
 
+var _ TxProvider = &LocalTxProvider{}
+
+// LocalSigner signs provider txs with a key from a keyring on the node
 type LocalSigner struct {
 	KeyName    string
 	KeyringDir string
-	codec      codec.Codec
-	txConfig   client.TxConfig
-	kb         keyring.Keyring
-	lg         log.Logger
+	// KeyringBackend is the backend of the keyring holding the key, test when unset
+	KeyringBackend string
+	SignerOptions
+	codec    codec.Codec
+	txConfig client.TxConfig
+	kb       keyring.Keyring
+	lg       log.Logger
+	sequence proposalSequence
 }
 
 type LocalTxProvider struct {
 	Logger     log.Logger
 	Codec      codec.Codec
-	Signer     LocalSigner
+	Signer     Signer
 	TxConfig   client.TxConfig
 	AcctKeeper authkeeper.AccountKeeper
+	// Strategy decides how the proposal is built, see NewStrategy
+	Strategy ProposalStrategy
 }
 
 func (bp *LocalTxProvider) Init() error {
@@ -69,11 +79,22 @@ func (ls *LocalSigner) Init(txCfg client.TxConfig, cdc codec.Codec, logger log.L
 	ls.codec = cdc
 	ls.lg = logger
 
-	kb, err := keyring.New("cosmos", keyring.BackendTest, ls.KeyringDir, nil, ls.codec)
+	backend := ls.KeyringBackend
+	if backend == "" {
+		backend = keyring.BackendTest
+	}
+	kb, err := keyring.New("cosmos", backend, ls.KeyringDir, os.Stdin, ls.codec)
 	if err != nil {
 		return err
 	}
 	ls.kb = kb
+
+	// unlock protected keyrings at startup rather than while building a proposal
+	if backend != keyring.BackendTest {
+		if _, err := kb.Key(ls.KeyName); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -108,15 +129,24 @@ func (ls *LocalSigner) RetreiveSigner(ctx sdk.Context, actKeeper authkeeper.Acco
 }
 
 func (ls *LocalSigner) BuildAndSignTx(ctx sdk.Context, acct types.AccountI, msg nstypes.MsgBid) sdk.Tx {
-	factory := tx.Factory{}.
-		WithTxConfig(ls.txConfig).
+	return ls.buildAndSignTx(ctx, acct, &msg)
+}
+
+func (ls *LocalSigner) buildAndSignTx(ctx sdk.Context, acct types.AccountI, msgs ...sdk.Msg) sdk.Tx {
+	sequence := ls.sequence.next(acct)
+	factory := ls.factory(ctx, ls.txConfig, acct, sequence).
 		WithKeybase(ls.kb).
-		WithChainID(ctx.ChainID()).
-		WithAccountNumber(acct.GetAccountNumber()).
-		WithSequence(acct.GetSequence()).
-		WithFees("50uatom")
+		WithSimulateAndExecute(true)
 
-	txBuilder, err := factory.BuildUnsignedTx(&msg)
+	gas, err := ls.proposalGas(&ls.sequence, factory, msgs...)
+	if err != nil {
+		ls.lg.Error(fmt.Sprintf("Error simulating tx: %v", err))
+
+		return nil
+	}
+	factory = factory.WithGas(gas)
+
+	txBuilder, err := factory.BuildUnsignedTx(msgs...)
 	if err != nil {
 		ls.lg.Error(fmt.Sprintf("Error building unsigned tx: %v", err))
 
@@ -130,9 +160,16 @@ func (ls *LocalSigner) BuildAndSignTx(ctx sdk.Context, acct types.AccountI, msg
 
 		return nil
 	}
+
+	ls.sequence.signed(sequence)
 	return txBuilder.GetTx()
 }
 
+// ResetSequence makes the next signed tx use the account's sequence from state again
+func (ls *LocalSigner) ResetSequence() {
+	ls.sequence.reset()
+}
+
 func (b *LocalTxProvider) getMatchingBid(ctx sdk.Context, bid *nstypes.MsgBid) sdk.Tx {
 	acct, err := b.Signer.RetreiveSigner(ctx, b.AcctKeeper)
 	if err != nil {
@@ -152,30 +189,16 @@ func (b *LocalTxProvider) getMatchingBid(ctx sdk.Context, bid *nstypes.MsgBid) s
 	return newTx
 }
 
+// BuildProposal builds the proposal with the configured strategy, front-running ns bids when
+// none is set
 func (b *LocalTxProvider) BuildProposal(ctx sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error) {
 	b.Logger.Info("💨 :: Building Proposal")
 
-	var newProposal []sdk.Tx
-	for _, tx := range proposalTxs {
-		sdkMsgs := tx.GetMsgs()
-		for _, msg := range sdkMsgs {
-			switch msg := msg.(type) {
-			case *nstypes.MsgBid:
-				b.Logger.Info("💨 :: Found a Bid to Snipe")
-
-				// Get matching bid from matching engine
-				newTx := b.getMatchingBid(ctx, msg)
-
-				// First append sniped Bid
-				newProposal = append(newProposal, newTx)
-				newProposal = append(newProposal, tx)
-			default:
-				// Append all other transactions
-				newProposal = append(newProposal, tx)
-			}
+	// every proposal is signed on top of the committed account sequence
+	b.Signer.ResetSequence()
 
-		}
+	if b.Strategy == nil {
+		b.Strategy = &frontRunStrategy{provider: b}
 	}
-
-	return newProposal, nil
+	return b.Strategy.BuildProposal(ctx, proposalTxs)
 }
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cosmossdk.io/log"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	"github.com/cosmos/cosmos-sdk/x/auth/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+/*
+	RemoteSigner signs provider txs with a secp256k1 key held by a remote signing service, so
+	the key never has to be on the node. The node builds the txs and only sends their sign bytes
+	over, using a small JSON over HTTP protocol:
+
+		GET  <url>/pubkey?key=<name>                        -> RemotePubKeyResponse
+		POST <url>/sign   RemoteSignRequest                 -> RemoteSignResponse
+*/
+
+// RemotePubKeyResponse is the remote signer's response to a public key request
+type RemotePubKeyResponse struct {
+	// PubKey is the compressed secp256k1 public key of the requested key
+	PubKey []byte `json:"pub_key"`
+}
+
+// RemoteSignRequest asks the remote signer to sign bytes with the given key
+type RemoteSignRequest struct {
+	Key       string `json:"key"`
+	SignBytes []byte `json:"sign_bytes"`
+}
+
+// RemoteSignResponse is the remote signer's response to a sign request
+type RemoteSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// remoteSignerTimeout bounds each call to the remote signer, as it runs while building a proposal
+const remoteSignerTimeout = 2 * time.Second
+
+type RemoteSigner struct {
+	URL     string
+	KeyName string
+	// Client is the http client used to reach the remote signer, a client with a short timeout when unset
+	Client *http.Client
+	SignerOptions
+	pubKey   cryptotypes.PubKey
+	txConfig client.TxConfig
+	lg       log.Logger
+	sequence proposalSequence
+}
+
+func (rs *RemoteSigner) Init(txCfg client.TxConfig, _ codec.Codec, logger log.Logger) error {
+	if len(rs.URL) == 0 {
+		return fmt.Errorf("remote signer url must be set")
+	}
+
+	rs.txConfig = txCfg
+	rs.lg = logger
+	if rs.Client == nil {
+		rs.Client = &http.Client{Timeout: remoteSignerTimeout}
+	}
+
+	// fetch the key up front, so a misconfigured signer is noticed at startup
+	res, err := rs.Client.Get(rs.URL + "/pubkey?key=" + url.QueryEscape(rs.KeyName))
+	if err != nil {
+		return err
+	}
+	var pubKeyRes RemotePubKeyResponse
+	if err := decodeRemoteResponse(res, &pubKeyRes); err != nil {
+		return err
+	}
+	if len(pubKeyRes.PubKey) != secp256k1.PubKeySize {
+		return fmt.Errorf("invalid public key length %d from remote signer", len(pubKeyRes.PubKey))
+	}
+	rs.pubKey = &secp256k1.PubKey{Key: pubKeyRes.PubKey}
+	return nil
+}
+
+func (rs *RemoteSigner) RetreiveSigner(ctx sdk.Context, actKeeper authkeeper.AccountKeeper) (types.AccountI, error) {
+	acct := actKeeper.GetAccount(ctx, sdk.AccAddress(rs.pubKey.Address()))
+	if acct == nil {
+		return nil, fmt.Errorf("account %s of the remote signer not found", sdk.AccAddress(rs.pubKey.Address()))
+	}
+	return acct, nil
+}
+
+func (rs *RemoteSigner) BuildAndSignTx(ctx sdk.Context, acct types.AccountI, msg nstypes.MsgBid) sdk.Tx {
+	return rs.buildAndSignTx(ctx, acct, &msg)
+}
+
+func (rs *RemoteSigner) buildAndSignTx(ctx sdk.Context, acct types.AccountI, msgs ...sdk.Msg) sdk.Tx {
+	sequence := rs.sequence.next(acct)
+	factory := rs.factory(ctx, rs.txConfig, acct, sequence).
+		WithSignMode(signing.SignMode_SIGN_MODE_DIRECT)
+
+	gas, err := rs.proposalGas(&rs.sequence, factory, msgs...)
+	if err != nil {
+		rs.lg.Error(fmt.Sprintf("Error simulating tx: %v", err))
+		return nil
+	}
+	factory = factory.WithGas(gas)
+
+	txBuilder, err := factory.BuildUnsignedTx(msgs...)
+	if err != nil {
+		rs.lg.Error(fmt.Sprintf("Error building unsigned tx: %v", err))
+		return nil
+	}
+
+	if err := rs.signTx(ctx, factory, txBuilder); err != nil {
+		rs.lg.Error(fmt.Sprintf("Error signing tx with the remote signer: %v", err))
+		return nil
+	}
+
+	rs.sequence.signed(sequence)
+	return txBuilder.GetTx()
+}
+
+// signTx signs the tx in SIGN_MODE_DIRECT with the remote key, following client/tx.Sign
+func (rs *RemoteSigner) signTx(ctx sdk.Context, factory tx.Factory, txBuilder client.TxBuilder) error {
+	signMode := factory.SignMode()
+	sig := signing.SignatureV2{
+		PubKey:   rs.pubKey,
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: factory.Sequence(),
+	}
+	// the signer infos are part of the sign bytes, so they are set before signing
+	if err := txBuilder.SetSignatures(sig); err != nil {
+		return err
+	}
+
+	signerData := authsigning.SignerData{
+		ChainID:       factory.ChainID(),
+		AccountNumber: factory.AccountNumber(),
+		Sequence:      factory.Sequence(),
+		PubKey:        rs.pubKey,
+		Address:       sdk.AccAddress(rs.pubKey.Address()).String(),
+	}
+	signBytes, err := authsigning.GetSignBytesAdapter(ctx, rs.txConfig.SignModeHandler(), signMode, signerData, txBuilder.GetTx())
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(RemoteSignRequest{Key: rs.KeyName, SignBytes: signBytes})
+	if err != nil {
+		return err
+	}
+	res, err := rs.Client.Post(rs.URL+"/sign", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	var signRes RemoteSignResponse
+	if err := decodeRemoteResponse(res, &signRes); err != nil {
+		return err
+	}
+	if !rs.pubKey.VerifySignature(signBytes, signRes.Signature) {
+		return fmt.Errorf("invalid signature from remote signer")
+	}
+
+	sig.Data = &signing.SingleSignatureData{SignMode: signMode, Signature: signRes.Signature}
+	return txBuilder.SetSignatures(sig)
+}
+
+// ResetSequence makes the next signed tx use the account's sequence from state again
+func (rs *RemoteSigner) ResetSequence() {
+	rs.sequence.reset()
+}
+
+func decodeRemoteResponse(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer responded with %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"fmt"
+
+	"cosmossdk.io/log"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
+	"github.com/cosmos/cosmos-sdk/x/auth/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// Names of the signers the tx provider can use
+const (
+	SignerLocal  = "local"
+	SignerRemote = "remote"
+)
+
+// defaultGasAdjustment leaves room for the gas the simulation doesn't account for
+const defaultGasAdjustment = 1.5
+
+// Signer builds and signs the txs the tx provider adds to a proposal
+type Signer interface {
+	Init(txCfg client.TxConfig, cdc codec.Codec, logger log.Logger) error
+	// RetreiveSigner returns the account the txs are signed for
+	RetreiveSigner(ctx sdk.Context, actKeeper authkeeper.AccountKeeper) (types.AccountI, error)
+	// BuildAndSignTx returns the signed tx, or nil if it could not be built
+	BuildAndSignTx(ctx sdk.Context, acct types.AccountI, msg nstypes.MsgBid) sdk.Tx
+	// ResetSequence is called before every proposal, as by then the txs signed for the
+	// previous one were either committed or discarded
+	ResetSequence()
+}
+
+var (
+	_ Signer = &LocalSigner{}
+	_ Signer = &RemoteSigner{}
+)
+
+// NewSigner creates the signer selected in the config for the given key
+func NewSigner(cfg Config, keyName, homeDir string, opts SignerOptions) (Signer, error) {
+	switch cfg.Signer {
+	case SignerLocal, "":
+		return &LocalSigner{
+			KeyName:        keyName,
+			KeyringDir:     homeDir,
+			KeyringBackend: cfg.KeyringBackend,
+			SignerOptions:  opts,
+		}, nil
+	case SignerRemote:
+		return &RemoteSigner{
+			URL:           cfg.RemoteSignerURL,
+			KeyName:       keyName,
+			SignerOptions: opts,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown signer: %s", cfg.Signer)
+	}
+}
+
+// SimulateFn simulates the execution of an encoded tx against the latest state
+type SimulateFn func(txBytes []byte) (sdk.GasInfo, *sdk.Result, error)
+
+// SignerOptions are the gas settings of the txs a signer builds
+type SignerOptions struct {
+	// GasPrices are the prices the gas of provider txs is paid at, e.g. the node's minimum gas prices
+	GasPrices string
+	// GasAdjustment scales the simulated gas to get the gas limit of provider txs
+	GasAdjustment float64
+	// Simulate is used to estimate the gas of provider txs, without it they get the default gas limit
+	Simulate SimulateFn
+}
+
+// factory returns a tx factory for the account at the given sequence
+func (o SignerOptions) factory(ctx sdk.Context, txConfig client.TxConfig, acct types.AccountI, sequence uint64) tx.Factory {
+	return tx.Factory{}.
+		WithTxConfig(txConfig).
+		WithChainID(ctx.ChainID()).
+		WithAccountNumber(acct.GetAccountNumber()).
+		WithSequence(sequence).
+		WithGasPrices(o.GasPrices).
+		WithGasAdjustment(o.gasAdjustment())
+}
+
+// estimateGas simulates the msgs to find the gas limit of the tx carrying them
+func (o SignerOptions) estimateGas(factory tx.Factory, msgs ...sdk.Msg) (uint64, error) {
+	if o.Simulate == nil {
+		return flags.DefaultGasLimit, nil
+	}
+
+	txBytes, err := factory.BuildSimTx(msgs...)
+	if err != nil {
+		return 0, err
+	}
+	gasInfo, _, err := o.Simulate(txBytes)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(factory.GasAdjustment() * float64(gasInfo.GasUsed)), nil
+}
+
+func (o SignerOptions) gasAdjustment() float64 {
+	if o.GasAdjustment <= 0 {
+		return defaultGasAdjustment
+	}
+	return o.GasAdjustment
+}
+
+// proposalGas returns the gas limit of a tx signed for the current proposal. Only the first
+// one is simulated: simulation runs against the committed state, so the txs signed after it,
+// at sequences ahead of the account's sequence in state, fail with a wrong sequence. They
+// carry the same kind of msgs and reuse its gas limit.
+func (o SignerOptions) proposalGas(s *proposalSequence, factory tx.Factory, msgs ...sdk.Msg) (uint64, error) {
+	if s.gas > 0 {
+		return s.gas, nil
+	}
+	gas, err := o.estimateGas(factory, msgs...)
+	if err != nil {
+		return 0, err
+	}
+	s.gas = gas
+	return gas, nil
+}
+
+// proposalSequence tracks the sequence of the next tx signed for the current proposal, as the
+// account's sequence in state doesn't move until the proposal is committed, and the gas limit
+// estimated for the proposal's txs
+type proposalSequence struct {
+	sequence uint64
+	set      bool
+	gas      uint64
+}
+
+func (s *proposalSequence) next(acct types.AccountI) uint64 {
+	if s.set && s.sequence > acct.GetSequence() {
+		return s.sequence
+	}
+	return acct.GetSequence()
+}
+
+func (s *proposalSequence) signed(sequence uint64) {
+	s.sequence = sequence + 1
+	s.set = true
+}
+
+func (s *proposalSequence) reset() {
+	s.sequence = 0
+	s.set = false
+	s.gas = 0
+}
@@ -0,0 +1,130 @@
+package provider_test
+
+import (
+	"testing"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	"github.com/ciprianmuja/weight-shift/provider"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+)
+
+func signerContext() sdk.Context {
+	return testutil.DefaultContext(storetypes.NewKVStoreKey("provider"), storetypes.NewTransientStoreKey("transient_provider")).
+		WithChainID("test")
+}
+
+func signatureSequence(t *testing.T, tx sdk.Tx) uint64 {
+	t.Helper()
+	sigs, err := tx.(authsigning.SigVerifiableTx).GetSignaturesV2()
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	return sigs[0].Sequence
+}
+
+func TestLocalSignerSequence(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig(bank.AppModuleBasic{})
+	dir := t.TempDir()
+	kb, err := keyring.New("cosmos", keyring.BackendTest, dir, nil, encCfg.Marshaler)
+	require.NoError(t, err)
+	record, _, err := kb.NewMnemonic("val", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	addr, err := record.GetAddress()
+	require.NoError(t, err)
+
+	var simulated int
+	signer := &provider.LocalSigner{
+		KeyName:    "val",
+		KeyringDir: dir,
+		SignerOptions: provider.SignerOptions{
+			GasPrices: "0.1uatom",
+			Simulate: func([]byte) (sdk.GasInfo, *sdk.Result, error) {
+				simulated++
+				return sdk.GasInfo{GasUsed: 1000}, &sdk.Result{}, nil
+			},
+		},
+	}
+	require.NoError(t, signer.Init(encCfg.TxConfig, encCfg.Marshaler, log.NewNopLogger()))
+
+	ctx := signerContext()
+	acct := authtypes.NewBaseAccount(addr, nil, 7, 5)
+	msg := banktypes.NewMsgSend(addr, addr, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)))
+
+	// txs signed for the same proposal use consecutive sequences
+	first := signer.SignMsgs(ctx, acct, msg)
+	require.NotNil(t, first)
+	second := signer.SignMsgs(ctx, acct, msg)
+	require.NotNil(t, second)
+	require.Equal(t, uint64(5), signatureSequence(t, first))
+	require.Equal(t, uint64(6), signatureSequence(t, second))
+
+	// the gas limit comes from the simulation of the proposal's first tx and the fee from the
+	// gas prices
+	require.Equal(t, 1, simulated)
+	for _, tx := range []sdk.Tx{first, second} {
+		feeTx := tx.(sdk.FeeTx)
+		require.Equal(t, uint64(1500), feeTx.GetGas())
+		require.Equal(t, sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(150))), feeTx.GetFee())
+	}
+
+	// the next proposal starts from the sequence in state again
+	signer.ResetSequence()
+	require.Equal(t, uint64(5), signatureSequence(t, signer.SignMsgs(ctx, acct, msg)))
+	require.Equal(t, 2, simulated)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig(bank.AppModuleBasic{})
+	privKey := secp256k1.GenPrivKey()
+	server := testutils.NewMockRemoteSigner(map[string]cryptotypes.PrivKey{"val": privKey})
+	defer server.Close()
+
+	// unknown keys are rejected at startup
+	unknown := &provider.RemoteSigner{URL: server.URL, KeyName: "other"}
+	require.Error(t, unknown.Init(encCfg.TxConfig, encCfg.Marshaler, log.NewNopLogger()))
+
+	signer, err := provider.NewSigner(provider.Config{Signer: provider.SignerRemote, RemoteSignerURL: server.URL}, "val", "", provider.SignerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, signer.Init(encCfg.TxConfig, encCfg.Marshaler, log.NewNopLogger()))
+
+	addr := sdk.AccAddress(privKey.PubKey().Address())
+	acct := authtypes.NewBaseAccount(addr, privKey.PubKey(), 3, 9)
+	msg := banktypes.NewMsgSend(addr, addr, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)))
+
+	ctx := signerContext()
+	tx := signer.(*provider.RemoteSigner).SignMsgs(ctx, acct, msg)
+	require.NotNil(t, tx)
+	require.Equal(t, uint64(9), signatureSequence(t, tx))
+	require.Equal(t, uint64(10), signatureSequence(t, signer.(*provider.RemoteSigner).SignMsgs(ctx, acct, msg)))
+
+	// the signature verifies against the remote key
+	sigs, err := tx.(authsigning.SigVerifiableTx).GetSignaturesV2()
+	require.NoError(t, err)
+	require.True(t, sigs[0].PubKey.Equals(privKey.PubKey()))
+	signBytes, err := authsigning.GetSignBytesAdapter(ctx, encCfg.TxConfig.SignModeHandler(), sigs[0].Data.(*signing.SingleSignatureData).SignMode,
+		authsigning.SignerData{ChainID: "test", AccountNumber: 3, Sequence: 9, PubKey: privKey.PubKey(), Address: addr.String()}, tx)
+	require.NoError(t, err)
+	require.True(t, privKey.PubKey().VerifySignature(signBytes, sigs[0].Data.(*signing.SingleSignatureData).Signature))
+}
+
+func TestNewSigner(t *testing.T) {
+	signer, err := provider.NewSigner(provider.DefaultConfig(), "val", "/home", provider.SignerOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "test", signer.(*provider.LocalSigner).KeyringBackend)
+
+	_, err = provider.NewSigner(provider.Config{Signer: "hsm"}, "val", "/home", provider.SignerOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// Names of the registered proposal strategies
+const (
+	StrategyPassthrough = "passthrough"
+	StrategyFeePriority = "fee-priority"
+	StrategyFrontRun    = "front-run"
+	StrategyFIFO        = "fifo"
+)
+
+// ProposalStrategy is a policy for ordering and extending the txs of a proposal
+type ProposalStrategy interface {
+	BuildProposal(ctx sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error)
+}
+
+// StrategyFactory creates a strategy for the given provider
+type StrategyFactory func(provider *LocalTxProvider) ProposalStrategy
+
+var strategies = map[string]StrategyFactory{
+	StrategyPassthrough: func(*LocalTxProvider) ProposalStrategy { return passthroughStrategy{} },
+	StrategyFeePriority: func(*LocalTxProvider) ProposalStrategy { return feePriorityStrategy{} },
+	StrategyFrontRun:    func(p *LocalTxProvider) ProposalStrategy { return &frontRunStrategy{provider: p} },
+	StrategyFIFO:        func(p *LocalTxProvider) ProposalStrategy { return newFIFOStrategy(p.TxConfig.TxEncoder()) },
+}
+
+// RegisterStrategy makes a strategy selectable by name, replacing any strategy registered
+// under the same name
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategies[name] = factory
+}
+
+// NewStrategy creates the strategy registered under the given name
+func NewStrategy(name string, provider *LocalTxProvider) (ProposalStrategy, error) {
+	factory, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown proposal strategy: %s", name)
+	}
+	return factory(provider), nil
+}
+
+// passthroughStrategy proposes the txs in the order the mempool returned them
+type passthroughStrategy struct{}
+
+func (passthroughStrategy) BuildProposal(_ sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error) {
+	return proposalTxs, nil
+}
+
+// feePriorityStrategy orders the txs by the fee they pay per unit of gas, highest first. Txs
+// paying the same gas price keep their mempool order.
+type feePriorityStrategy struct{}
+
+func (feePriorityStrategy) BuildProposal(_ sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error) {
+	ordered := make([]sdk.Tx, len(proposalTxs))
+	copy(ordered, proposalTxs)
+	prices := make(map[sdk.Tx]math.LegacyDec, len(ordered))
+	for _, tx := range ordered {
+		prices[tx] = gasPrice(tx)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return prices[ordered[i]].GT(prices[ordered[j]])
+	})
+	return ordered, nil
+}
+
+// gasPrice returns the total fee amount paid per unit of gas
+func gasPrice(tx sdk.Tx) math.LegacyDec {
+	feeTx, ok := tx.(sdk.FeeTx)
+	if !ok || feeTx.GetGas() == 0 {
+		return math.LegacyZeroDec()
+	}
+	total := math.ZeroInt()
+	for _, coin := range feeTx.GetFee() {
+		total = total.Add(coin.Amount)
+	}
+	return math.LegacyNewDecFromInt(total).QuoInt64(int64(feeTx.GetGas()))
+}
+
+// frontRunStrategy places a doubled bid of the provider's own in front of every ns bid
+type frontRunStrategy struct {
+	provider *LocalTxProvider
+}
+
+func (s *frontRunStrategy) BuildProposal(ctx sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error) {
+	var newProposal []sdk.Tx
+	for _, tx := range proposalTxs {
+		for _, msg := range tx.GetMsgs() {
+			if msg, ok := msg.(*nstypes.MsgBid); ok {
+				s.provider.Logger.Info("💨 :: Found a Bid to Snipe")
+
+				// Get matching bid from matching engine, placed in front of the sniped tx
+				if newTx := s.provider.getMatchingBid(ctx, msg); newTx != nil {
+					newProposal = append(newProposal, newTx)
+				}
+			}
+		}
+		newProposal = append(newProposal, tx)
+	}
+
+	return newProposal, nil
+}
+
+// fifoStrategy orders the txs by when this node first saw them in a proposal, so a tx that
+// was left out of an earlier block is not overtaken by txs that arrived after it
+type fifoStrategy struct {
+	txEncoder sdk.TxEncoder
+	next      uint64
+	seen      map[[sha256.Size]byte]uint64
+}
+
+func newFIFOStrategy(txEncoder sdk.TxEncoder) *fifoStrategy {
+	return &fifoStrategy{
+		txEncoder: txEncoder,
+		seen:      make(map[[sha256.Size]byte]uint64),
+	}
+}
+
+func (s *fifoStrategy) BuildProposal(_ sdk.Context, proposalTxs []sdk.Tx) ([]sdk.Tx, error) {
+	seen := make(map[[sha256.Size]byte]uint64, len(proposalTxs))
+	arrivals := make(map[sdk.Tx]uint64, len(proposalTxs))
+	for _, tx := range proposalTxs {
+		bz, err := s.txEncoder(tx)
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(bz)
+
+		arrival, ok := s.seen[hash]
+		if !ok {
+			arrival = s.next
+			s.next++
+		}
+		seen[hash] = arrival
+		arrivals[tx] = arrival
+	}
+	// forget the txs that are no longer in the mempool
+	s.seen = seen
+
+	ordered := make([]sdk.Tx, len(proposalTxs))
+	copy(ordered, proposalTxs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return arrivals[ordered[i]] < arrivals[ordered[j]]
+	})
+	return ordered, nil
+}
@@ -0,0 +1,126 @@
+package provider_test
+
+import (
+	"testing"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"github.com/ciprianmuja/weight-shift/provider"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+func feeTx(txConfig client.TxConfig, memo string, fee, gas int64) sdk.Tx {
+	builder := txConfig.NewTxBuilder()
+	builder.SetMemo(memo)
+	builder.SetFeeAmount(sdk.NewCoins(sdk.NewCoin("uatom", math.NewInt(fee))))
+	builder.SetGasLimit(uint64(gas))
+	return builder.GetTx()
+}
+
+func memos(txs []sdk.Tx) []string {
+	var memos []string
+	for _, tx := range txs {
+		memos = append(memos, tx.(sdk.TxWithMemo).GetMemo())
+	}
+	return memos
+}
+
+func buildWith(t *testing.T, txConfig client.TxConfig, name string, txs ...sdk.Tx) []string {
+	t.Helper()
+	strategy, err := provider.NewStrategy(name, &provider.LocalTxProvider{TxConfig: txConfig})
+	require.NoError(t, err)
+	proposal, err := strategy.BuildProposal(sdk.Context{}, txs)
+	require.NoError(t, err)
+	return memos(proposal)
+}
+
+func TestProposalStrategies(t *testing.T) {
+	txConfig := testutils.MakeTestTxConfig()
+	cheap := feeTx(txConfig, "cheap", 100, 1000)
+	pricey := feeTx(txConfig, "pricey", 100, 10)
+	average := feeTx(txConfig, "average", 50, 100)
+	alsoAverage := feeTx(txConfig, "also-average", 100, 200)
+
+	require.Equal(t, []string{"cheap", "pricey"}, buildWith(t, txConfig, provider.StrategyPassthrough, cheap, pricey))
+	require.Equal(t, []string{"pricey", "average", "also-average", "cheap"},
+		buildWith(t, txConfig, provider.StrategyFeePriority, cheap, average, pricey, alsoAverage))
+
+	_, err := provider.NewStrategy("unknown", &provider.LocalTxProvider{})
+	require.Error(t, err)
+}
+
+func TestFIFOStrategy(t *testing.T) {
+	txConfig := testutils.MakeTestTxConfig()
+	strategy, err := provider.NewStrategy(provider.StrategyFIFO, &provider.LocalTxProvider{TxConfig: txConfig})
+	require.NoError(t, err)
+
+	first := feeTx(txConfig, "first", 1, 1)
+	second := feeTx(txConfig, "second", 1, 1)
+	third := feeTx(txConfig, "third", 1, 1)
+
+	proposal, err := strategy.BuildProposal(sdk.Context{}, []sdk.Tx{first, second})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second"}, memos(proposal))
+
+	// txs seen in an earlier proposal stay ahead of the ones that arrived later
+	proposal, err = strategy.BuildProposal(sdk.Context{}, []sdk.Tx{third, second})
+	require.NoError(t, err)
+	require.Equal(t, []string{"second", "third"}, memos(proposal))
+
+	// once a tx left the mempool it is treated as new when it shows up again
+	proposal, err = strategy.BuildProposal(sdk.Context{}, []sdk.Tx{first, third})
+	require.NoError(t, err)
+	require.Equal(t, []string{"third", "first"}, memos(proposal))
+}
+
+// memoTx is a tx made of its memo and messages
+type memoTx struct {
+	memo string
+	msgs []sdk.Msg
+}
+
+func (tx memoTx) GetMsgs() []sdk.Msg                    { return tx.msgs }
+func (tx memoTx) GetMsgsV2() ([]protov2.Message, error) { return nil, nil }
+func (tx memoTx) GetMemo() string                       { return tx.memo }
+
+// bidSigner signs every bid into a tx memoed with the name it bids for
+type bidSigner struct{}
+
+func (bidSigner) Init(client.TxConfig, codec.Codec, log.Logger) error { return nil }
+func (bidSigner) RetreiveSigner(sdk.Context, authkeeper.AccountKeeper) (authtypes.AccountI, error) {
+	return authtypes.NewBaseAccountWithAddress(sdk.AccAddress("provider____________")), nil
+}
+func (bidSigner) BuildAndSignTx(_ sdk.Context, _ authtypes.AccountI, msg nstypes.MsgBid) sdk.Tx {
+	return memoTx{memo: "front-run " + msg.Name}
+}
+func (bidSigner) ResetSequence() {}
+
+func TestFrontRunStrategy(t *testing.T) {
+	txProvider := &provider.LocalTxProvider{Logger: log.NewNopLogger(), Signer: bidSigner{}}
+	strategy, err := provider.NewStrategy(provider.StrategyFrontRun, txProvider)
+	require.NoError(t, err)
+
+	bid := func(name string) sdk.Msg {
+		return &nstypes.MsgBid{Name: name, Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 100))}
+	}
+	send := &banktypes.MsgSend{}
+
+	// every tx is proposed once, whatever the number of msgs it carries, behind the bids made
+	// for the bids it contains
+	proposal, err := strategy.BuildProposal(sdk.Context{}, []sdk.Tx{
+		memoTx{memo: "sends", msgs: []sdk.Msg{send, send}},
+		memoTx{memo: "bids", msgs: []sdk.Msg{bid("alice.ns"), send, bid("bob.ns")}},
+		memoTx{memo: "empty"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"sends", "front-run alice.ns", "front-run bob.ns", "bids", "empty"}, memos(proposal))
+}
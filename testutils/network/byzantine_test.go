@@ -0,0 +1,236 @@
+package network_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/abci"
+	"github.com/ciprianmuja/weight-shift/testutils/network"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/stretchr/testify/require"
+)
+
+// honestWeights returns the weights an honest network stores after the given number of heights
+func honestWeights(t *testing.T, heights int) map[string]int64 {
+	t.Helper()
+	n := network.New(t, network.DefaultConfig())
+	n.NextBlocks(heights)
+	return n.Weights(n.Validators[0])
+}
+
+// rewriteVoteExt decodes a vote extension, changes it and encodes it again
+func rewriteVoteExt(t *testing.T, bz []byte, rewrite func(voteExt *abci.WeightedVotingPowerVoteExtension)) []byte {
+	t.Helper()
+	var voteExt abci.WeightedVotingPowerVoteExtension
+	require.NoError(t, json.Unmarshal(bz, &voteExt))
+	rewrite(&voteExt)
+	bz, err := json.Marshal(voteExt)
+	require.NoError(t, err)
+	return bz
+}
+
+func TestByzantineValidator(t *testing.T) {
+	testCases := []struct {
+		name string
+		// extendVote rewrites the vote extensions of the byzantine validator
+		extendVote func(t *testing.T, n *network.Network) func(height int64, voteExt []byte) []byte
+		// rejectedFrom is the first height the vote extension of the validator is rejected at
+		rejectedFrom int64
+	}{
+		{
+			name: "out of range weight",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(_ int64, voteExt []byte) []byte {
+					return rewriteVoteExt(t, voteExt, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+						voteExt.Weights[string(n.Validators[0].ConsAddress())] = weightskeeper.MaxWeight + 1
+					})
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "negative weight",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(_ int64, voteExt []byte) []byte {
+					return rewriteVoteExt(t, voteExt, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+						voteExt.Weights[string(n.Validators[1].ConsAddress())] = -1
+					})
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "weight for an unknown validator",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(_ int64, voteExt []byte) []byte {
+					return rewriteVoteExt(t, voteExt, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+						voteExt.Weights["val1"] = 10
+					})
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "metric for an unknown validator",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(_ int64, voteExt []byte) []byte {
+					return rewriteVoteExt(t, voteExt, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+						voteExt.Metrics[weightskeeper.MetricUptime]["val1"] = 100
+					})
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "oversized extension",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(_ int64, voteExt []byte) []byte {
+					return rewriteVoteExt(t, voteExt, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+						voteExt.Salt = make([]byte, abci.MaxVoteExtensionSize)
+					})
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "omitted extension",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				return func(int64, []byte) []byte {
+					return nil
+				}
+			},
+			rejectedFrom: 2,
+		},
+		{
+			name: "replayed extension",
+			extendVote: func(t *testing.T, n *network.Network) func(int64, []byte) []byte {
+				var previous []byte
+				return func(_ int64, voteExt []byte) []byte {
+					replayed := previous
+					previous = voteExt
+					if replayed == nil {
+						return voteExt
+					}
+					return replayed
+				}
+			},
+			rejectedFrom: 3,
+		},
+	}
+
+	const heights = 5
+	expected := honestWeights(t, heights)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := network.New(t, network.DefaultConfig())
+			byzantine := n.Validators[len(n.Validators)-1]
+			byzantine.ExtendVote = tc.extendVote(t, n)
+
+			for i := 0; i < heights; i++ {
+				block := n.NextBlock()
+				if block.VoteExtensions == nil || block.Height < tc.rejectedFrom {
+					require.Len(t, block.Rejected, 0, "height %d", block.Height)
+					continue
+				}
+				// the honest validators reject the extension, which leaves the vote out of the
+				// commit the next height aggregates the weights of
+				require.Len(t, block.Rejected, 1, "height %d", block.Height)
+				require.True(t, block.Rejected[0] == byzantine, "height %d", block.Height)
+			}
+
+			n.RequireWeights(expected)
+		})
+	}
+}
+
+func TestByzantineProposer(t *testing.T) {
+	testCases := []struct {
+		name string
+		// tamper changes the injected tx of a proposal made at the given height
+		tamper func(t *testing.T, n *network.Network, height int64, injected *abci.WeightedVotingPower)
+	}{
+		{
+			name: "fabricated weights",
+			tamper: func(t *testing.T, n *network.Network, _ int64, injected *abci.WeightedVotingPower) {
+				injected.StakeWeightedWeighted[string(n.Validators[0].ConsAddress())] = weightskeeper.MaxWeight
+			},
+		},
+		{
+			name: "weights for an unreported validator",
+			tamper: func(t *testing.T, _ *network.Network, _ int64, injected *abci.WeightedVotingPower) {
+				injected.StakeWeightedWeighted["proposer"] = weightskeeper.MaxWeight
+			},
+		},
+		{
+			name: "vote left out",
+			tamper: func(t *testing.T, _ *network.Network, _ int64, injected *abci.WeightedVotingPower) {
+				injected.ExtendedCommitInfo.Votes = injected.ExtendedCommitInfo.Votes[1:]
+			},
+		},
+		{
+			name: "absent vote counted",
+			tamper: func(t *testing.T, _ *network.Network, _ int64, injected *abci.WeightedVotingPower) {
+				injected.ExtendedCommitInfo.Votes[0].BlockIdFlag = cmtproto.BlockIDFlagAbsent
+			},
+		},
+		{
+			name: "forged extension",
+			tamper: func(t *testing.T, n *network.Network, _ int64, injected *abci.WeightedVotingPower) {
+				vote := &injected.ExtendedCommitInfo.Votes[0]
+				vote.VoteExtension = rewriteVoteExt(t, vote.VoteExtension, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+					voteExt.Weights[string(n.Validators[0].ConsAddress())] = weightskeeper.MaxWeight
+				})
+			},
+		},
+		{
+			name: "replayed signed extension",
+			tamper: func(t *testing.T, n *network.Network, height int64, injected *abci.WeightedVotingPower) {
+				// the extension the validator made and signed two heights earlier
+				v := n.Validators[0]
+				vote := &injected.ExtendedCommitInfo.Votes[0]
+				vote.VoteExtension = rewriteVoteExt(t, vote.VoteExtension, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+					voteExt.Height = height - 2
+				})
+				vote.ExtensionSignature = n.SignVoteExtension(v, vote.VoteExtension, height-2)
+			},
+		},
+		{
+			name: "extension signed for its height with a stale payload",
+			tamper: func(t *testing.T, n *network.Network, height int64, injected *abci.WeightedVotingPower) {
+				// a byzantine validator signs the extension it made at an earlier height again
+				v := n.Validators[0]
+				vote := &injected.ExtendedCommitInfo.Votes[0]
+				vote.VoteExtension = rewriteVoteExt(t, vote.VoteExtension, func(voteExt *abci.WeightedVotingPowerVoteExtension) {
+					voteExt.Height = height - 2
+				})
+				vote.ExtensionSignature = n.SignVoteExtension(v, vote.VoteExtension, height-1)
+			},
+		},
+	}
+
+	const heights = 4
+	expected := honestWeights(t, heights)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := network.New(t, network.DefaultConfig())
+			n.NextBlocks(heights - 1)
+
+			proposal := n.Propose()
+			var injected abci.WeightedVotingPower
+			require.NoError(t, json.Unmarshal(proposal.Txs[0], &injected))
+			tc.tamper(t, n, proposal.Height, &injected)
+			bz, err := json.Marshal(injected)
+			require.NoError(t, err)
+			proposal.Txs[0] = bz
+
+			// every validator rejects the proposal, so the height goes to an honest proposal of
+			// the next round
+			require.Len(t, n.Process(proposal), len(n.Validators))
+			n.NextBlock()
+			n.RequireWeights(expected)
+		})
+	}
+}
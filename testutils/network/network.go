@@ -0,0 +1,562 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"github.com/ciprianmuja/weight-shift/app"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtcrypto "github.com/cometbft/cometbft/crypto"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	clienttx "github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	simtestutil "github.com/cosmos/cosmos-sdk/testutil/sims"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	protoio "github.com/cosmos/gogoproto/io"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+	Network runs a chain of in-process App instances, each on its own in-memory DB, driving
+	every height through the ABCI++ calls CometBFT makes:
+
+	1. the proposer of the height prepares a proposal from the vote extensions of the last commit
+	2. every validator processes the proposal
+	3. every validator extends its vote, and the others verify the extension
+	4. every validator finalizes and commits the block, which must yield the same app hash
+
+	Keys and times are derived from fixed seeds, so a network replays identically.
+*/
+
+// ChainID is the chain id of the network, which the vote extensions are signed for
+const ChainID = "weight-shift-test"
+
+// GenesisTime is the time of the genesis of the network
+var GenesisTime = time.Unix(1700000000, 0).UTC()
+
+// BlockTime is the time between two heights
+const BlockTime = 5 * time.Second
+
+// Config configures a Network
+type Config struct {
+	// NumValidators is the number of validators, each running its own App
+	NumValidators int
+	// VoteExtensionsEnableHeight is the height vote extensions are enabled from, zero leaving
+	// them disabled
+	VoteExtensionsEnableHeight int64
+	// Accounts are funded with Balance at genesis
+	Accounts []sdk.AccAddress
+	Balance  sdk.Coins
+	// AppOptions are passed to every App
+	AppOptions simtestutil.AppOptionsMap
+}
+
+// DefaultConfig returns a network of four validators with vote extensions enabled from the
+// second height
+func DefaultConfig() Config {
+	return Config{
+		NumValidators:              4,
+		VoteExtensionsEnableHeight: 2,
+		AppOptions:                 simtestutil.AppOptionsMap{},
+	}
+}
+
+// Validator is a validator of the network along with the App it runs
+type Validator struct {
+	App *app.App
+	// PrivKey is the consensus key the validator signs its vote extensions with
+	PrivKey cmtcrypto.PrivKey
+	// Power is the voting power of the validator in the current validator set, zero once it
+	// left the set
+	Power int64
+	// ExtendVote, when set, replaces the vote extension the App of the validator made at the
+	// given height with the one it returns, to script a byzantine validator
+	ExtendVote func(height int64, voteExt []byte) []byte
+}
+
+// ConsAddress returns the consensus address of the validator
+func (v *Validator) ConsAddress() sdk.ConsAddress {
+	return sdk.ConsAddress(v.PrivKey.PubKey().Address())
+}
+
+// OperatorAddress returns the operator address the validator was created with at genesis
+func (v *Validator) OperatorAddress() sdk.ValAddress {
+	return sdk.ValAddress(v.ConsAddress())
+}
+
+// Proposal is a block proposed for the next height
+type Proposal struct {
+	Height   int64
+	Proposer *Validator
+	Txs      [][]byte
+}
+
+// Block is a finalized and committed height
+type Block struct {
+	Height   int64
+	Proposer *Validator
+	Txs      [][]byte
+	// VoteExtensions holds the vote extension of each validator for the block, in the order of
+	// Network.Validators. Validators outside the set have none.
+	VoteExtensions [][]byte
+	// Rejected holds the validators whose vote extension another validator rejected, which
+	// leaves their vote out of the next proposal
+	Rejected []*Validator
+	// Result is the FinalizeBlock response of the block, the same on every validator
+	Result *abci.ResponseFinalizeBlock
+}
+
+// Network is a chain of in-process validators, each running its own App
+type Network struct {
+	t          *testing.T
+	Validators []*Validator
+	// Delegator is a genesis account holding bond denom tokens, to delegate with
+	Delegator cryptotypes.PrivKey
+
+	height int64
+	params cmtproto.ConsensusParams
+	// lastCommit carries the vote extensions of the previous height into the next proposal
+	lastCommit abci.ExtendedCommitInfo
+	// valUpdates holds the validator updates of every height until they take effect, two
+	// heights later
+	valUpdates map[int64][]abci.ValidatorUpdate
+}
+
+// New starts a network with the given config, ready to produce its first height
+func New(t *testing.T, cfg Config) *Network {
+	t.Helper()
+	require.Positive(t, cfg.NumValidators)
+
+	n := &Network{
+		t:          t,
+		Delegator:  secp256k1.GenPrivKeyFromSecret([]byte("delegator")),
+		valUpdates: make(map[int64][]abci.ValidatorUpdate),
+	}
+
+	cmtValidators := make([]*cmttypes.Validator, cfg.NumValidators)
+	for i := range cmtValidators {
+		privKey := ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("validator-%d", i)))
+		n.Validators = append(n.Validators, &Validator{PrivKey: privKey})
+		cmtValidators[i] = cmttypes.NewValidator(privKey.PubKey(), 1)
+	}
+
+	appState := n.genesis(cfg, cmttypes.NewValidatorSet(cmtValidators))
+
+	n.params = *simtestutil.DefaultConsensusParams
+	n.params.Abci = &cmtproto.ABCIParams{VoteExtensionsEnableHeight: cfg.VoteExtensionsEnableHeight}
+	for _, v := range n.Validators {
+		v.App = app.NewApp(log.NewNopLogger(), dbm.NewMemDB(), nil, true, map[int64]bool{}, "",
+			cfg.AppOptions, baseapp.SetChainID(ChainID))
+
+		res, err := v.App.InitChain(&abci.RequestInitChain{
+			Time:            GenesisTime,
+			ChainId:         ChainID,
+			ConsensusParams: &n.params,
+			AppStateBytes:   appState,
+			InitialHeight:   1,
+		})
+		require.NoError(t, err)
+		n.applyValidatorUpdates(res.Validators)
+	}
+	return n
+}
+
+// genesis returns the app state of the network, in which the delegator has delegated to the
+// validators, bonded by staking at InitChain, and the accounts of the config are funded
+func (n *Network) genesis(cfg Config, valSet *cmttypes.ValidatorSet) []byte {
+	// the genesis does not depend on the app instance, any instance builds the same
+	genApp := app.NewApp(log.NewNopLogger(), dbm.NewMemDB(), nil, true, map[int64]bool{}, "", cfg.AppOptions)
+
+	delegator := sdk.AccAddress(n.Delegator.PubKey().Address())
+	genAccs := []authtypes.GenesisAccount{authtypes.NewBaseAccount(delegator, nil, 0, 0)}
+	balances := []banktypes.Balance{{
+		Address: delegator.String(),
+		Coins:   sdk.NewCoins(sdk.NewCoin(sdk.DefaultBondDenom, sdk.TokensFromConsensusPower(1000, sdk.DefaultPowerReduction))),
+	}}
+	for i, account := range cfg.Accounts {
+		genAccs = append(genAccs, authtypes.NewBaseAccount(account, nil, uint64(i+1), 0))
+		if !cfg.Balance.IsZero() {
+			balances = append(balances, banktypes.Balance{Address: account.String(), Coins: cfg.Balance})
+		}
+	}
+
+	genesis, err := simtestutil.GenesisStateWithValSet(genApp.AppCodec(), genApp.DefaultGenesis(), valSet, genAccs, balances...)
+	require.NoError(n.t, err)
+
+	// like validators joining through gentxs, the validators are bonded by staking in InitChain,
+	// which runs the staking hooks, so they start unbonded with their stake in the not bonded
+	// pool
+	stakingGenesis := stakingtypes.GetGenesisStateFromAppState(genApp.AppCodec(), genesis)
+	for i := range stakingGenesis.Validators {
+		stakingGenesis.Validators[i].Status = stakingtypes.Unbonded
+	}
+	genesis[stakingtypes.ModuleName] = genApp.AppCodec().MustMarshalJSON(stakingGenesis)
+
+	// the helper only credits the bonded pool and the supply with the stake of one validator
+	bondedPool := authtypes.NewModuleAddress(stakingtypes.BondedPoolName).String()
+	notBondedPool := authtypes.NewModuleAddress(stakingtypes.NotBondedPoolName).String()
+	staked := sdk.NewCoins(sdk.NewCoin(sdk.DefaultBondDenom, Tokens(int64(valSet.Size()))))
+	bankGenesis := banktypes.GetGenesisStateFromAppState(genApp.AppCodec(), genesis)
+	bankGenesis.Supply = sdk.NewCoins()
+	for i, balance := range bankGenesis.Balances {
+		if balance.Address == bondedPool {
+			bankGenesis.Balances[i] = banktypes.Balance{Address: notBondedPool, Coins: staked}
+		}
+		bankGenesis.Supply = bankGenesis.Supply.Add(bankGenesis.Balances[i].Coins...)
+	}
+	genesis[banktypes.ModuleName] = genApp.AppCodec().MustMarshalJSON(bankGenesis)
+
+	// the signing infos start at zero, the height the validators are bonded at
+	var slashingGenesis slashingtypes.GenesisState
+	genApp.AppCodec().MustUnmarshalJSON(genesis[slashingtypes.ModuleName], &slashingGenesis)
+	for _, val := range valSet.Validators {
+		consAddr := sdk.ConsAddress(val.Address)
+		slashingGenesis.SigningInfos = append(slashingGenesis.SigningInfos, slashingtypes.SigningInfo{
+			Address:              consAddr.String(),
+			ValidatorSigningInfo: slashingtypes.NewValidatorSigningInfo(consAddr, 0, 0, time.Unix(0, 0).UTC(), false, 0),
+		})
+	}
+	genesis[slashingtypes.ModuleName] = genApp.AppCodec().MustMarshalJSON(&slashingGenesis)
+
+	appState, err := json.Marshal(genesis)
+	require.NoError(n.t, err)
+	return appState
+}
+
+// Height returns the last committed height
+func (n *Network) Height() int64 {
+	return n.height
+}
+
+// Time returns the time of the given height
+func (n *Network) Time(height int64) time.Time {
+	return GenesisTime.Add(time.Duration(height) * BlockTime)
+}
+
+// Proposer returns the proposer of the next height, the validators of the set taking turns
+func (n *Network) Proposer() *Validator {
+	var active []*Validator
+	for _, v := range n.Validators {
+		if v.Power > 0 {
+			active = append(active, v)
+		}
+	}
+	require.NotEmpty(n.t, active, "the validator set is empty")
+	return active[int(n.height)%len(active)]
+}
+
+// VoteExtensionsEnabled reports whether validators extend their votes at the given height
+func (n *Network) VoteExtensionsEnabled(height int64) bool {
+	enableHeight := n.params.Abci.GetVoteExtensionsEnableHeight()
+	return enableHeight > 0 && height >= enableHeight
+}
+
+// Propose has the proposer of the next height prepare a proposal with the given txs
+func (n *Network) Propose(txs ...[]byte) *Proposal {
+	n.t.Helper()
+	height := n.height + 1
+	proposer := n.Proposer()
+
+	prepared, err := proposer.App.PrepareProposal(&abci.RequestPrepareProposal{
+		Height:          height,
+		Time:            n.Time(height),
+		Txs:             txs,
+		MaxTxBytes:      n.params.Block.MaxBytes,
+		LocalLastCommit: n.lastCommit,
+		ProposerAddress: proposer.ConsAddress(),
+	})
+	require.NoError(n.t, err)
+	return &Proposal{Height: height, Proposer: proposer, Txs: prepared.Txs}
+}
+
+// Process has every validator of the set process the proposal, returning the validators that
+// reject it
+func (n *Network) Process(p *Proposal) []*Validator {
+	n.t.Helper()
+	var rejected []*Validator
+	for _, v := range n.Validators {
+		if v.Power == 0 {
+			continue
+		}
+		res, err := v.App.ProcessProposal(&abci.RequestProcessProposal{
+			Height:             p.Height,
+			Time:               n.Time(p.Height),
+			Txs:                p.Txs,
+			ProposedLastCommit: n.lastCommitInfo(),
+			ProposerAddress:    p.Proposer.ConsAddress(),
+		})
+		require.NoError(n.t, err)
+		if res.Status != abci.ResponseProcessProposal_ACCEPT {
+			rejected = append(rejected, v)
+		}
+	}
+	return rejected
+}
+
+// Finalize has the validators vote on, finalize and commit the proposal, whether or not they
+// all accepted it
+func (n *Network) Finalize(p *Proposal) *Block {
+	n.t.Helper()
+	require.Equal(n.t, n.height+1, p.Height, "the proposal is not for the next height")
+
+	// the validator updates returned two heights ago take effect at this height
+	n.applyValidatorUpdates(n.valUpdates[p.Height-2])
+	delete(n.valUpdates, p.Height-2)
+
+	block := &Block{Height: p.Height, Proposer: p.Proposer, Txs: p.Txs}
+	if n.VoteExtensionsEnabled(p.Height) {
+		block.VoteExtensions, block.Rejected = n.extendVotes(p)
+	}
+
+	decidedLastCommit := n.lastCommitInfo()
+	for _, v := range n.Validators {
+		res, err := v.App.FinalizeBlock(&abci.RequestFinalizeBlock{
+			Height:            p.Height,
+			Time:              n.Time(p.Height),
+			Txs:               p.Txs,
+			DecidedLastCommit: decidedLastCommit,
+			ProposerAddress:   p.Proposer.ConsAddress(),
+		})
+		require.NoError(n.t, err)
+		if block.Result == nil {
+			block.Result = res
+		} else {
+			require.Equal(n.t, block.Result.AppHash, res.AppHash, "validators disagree on the app hash at height %d", p.Height)
+		}
+		_, err = v.App.Commit()
+		require.NoError(n.t, err)
+	}
+
+	n.height = p.Height
+	if block.Result.ConsensusParamUpdates != nil {
+		n.params = *block.Result.ConsensusParamUpdates
+	}
+	if len(block.Result.ValidatorUpdates) > 0 {
+		n.valUpdates[p.Height] = block.Result.ValidatorUpdates
+	}
+	n.lastCommit = n.extendedCommitInfo(block)
+	return block
+}
+
+// NextBlock proposes, votes on and commits the next height with the given txs, requiring
+// every validator to accept the proposal
+func (n *Network) NextBlock(txs ...[]byte) *Block {
+	n.t.Helper()
+	proposal := n.Propose(txs...)
+	require.Empty(n.t, n.Process(proposal), "validators rejected the proposal at height %d", proposal.Height)
+	return n.Finalize(proposal)
+}
+
+// NextBlocks commits the given number of empty heights, returning the last one
+func (n *Network) NextBlocks(count int) *Block {
+	n.t.Helper()
+	var block *Block
+	for i := 0; i < count; i++ {
+		block = n.NextBlock()
+	}
+	return block
+}
+
+// extendVotes has every validator of the set extend its vote on the proposal, and every other
+// validator verify the extension
+func (n *Network) extendVotes(p *Proposal) ([][]byte, []*Validator) {
+	voteExts := make([][]byte, len(n.Validators))
+	for i, v := range n.Validators {
+		if v.Power == 0 {
+			continue
+		}
+		res, err := v.App.ExtendVote(context.Background(), &abci.RequestExtendVote{
+			Height:          p.Height,
+			Time:            n.Time(p.Height),
+			Txs:             p.Txs,
+			ProposerAddress: p.Proposer.ConsAddress(),
+		})
+		require.NoError(n.t, err)
+		voteExts[i] = res.VoteExtension
+		if v.ExtendVote != nil {
+			voteExts[i] = v.ExtendVote(p.Height, res.VoteExtension)
+		}
+	}
+
+	var rejected []*Validator
+	for i, v := range n.Validators {
+		if v.Power == 0 {
+			continue
+		}
+		for _, verifier := range n.Validators {
+			if verifier == v || verifier.Power == 0 {
+				continue
+			}
+			res, err := verifier.App.VerifyVoteExtension(&abci.RequestVerifyVoteExtension{
+				Height:           p.Height,
+				ValidatorAddress: v.ConsAddress(),
+				VoteExtension:    voteExts[i],
+			})
+			if err != nil || res.Status != abci.ResponseVerifyVoteExtension_ACCEPT {
+				rejected = append(rejected, v)
+				break
+			}
+		}
+	}
+	return voteExts, rejected
+}
+
+// extendedCommitInfo returns the commit of the block, with the signed vote extensions of the
+// validators whose extension was accepted
+func (n *Network) extendedCommitInfo(block *Block) abci.ExtendedCommitInfo {
+	var info abci.ExtendedCommitInfo
+	for i, v := range n.Validators {
+		if v.Power == 0 {
+			continue
+		}
+		vote := abci.ExtendedVoteInfo{
+			Validator:   abci.Validator{Address: v.ConsAddress(), Power: v.Power},
+			BlockIdFlag: cmtproto.BlockIDFlagCommit,
+		}
+		if isRejected(block.Rejected, v) {
+			// CometBFT drops the precommits carrying an invalid extension
+			vote.BlockIdFlag = cmtproto.BlockIDFlagAbsent
+		} else if block.VoteExtensions != nil {
+			vote.VoteExtension = block.VoteExtensions[i]
+			vote.ExtensionSignature = n.SignVoteExtension(v, block.VoteExtensions[i], block.Height)
+		}
+		info.Votes = append(info.Votes, vote)
+	}
+	return info
+}
+
+// lastCommitInfo returns the last commit without its vote extensions, as CometBFT passes it to
+// ProcessProposal and FinalizeBlock
+func (n *Network) lastCommitInfo() abci.CommitInfo {
+	info := abci.CommitInfo{Round: n.lastCommit.Round}
+	for _, vote := range n.lastCommit.Votes {
+		info.Votes = append(info.Votes, abci.VoteInfo{Validator: vote.Validator, BlockIdFlag: vote.BlockIdFlag})
+	}
+	return info
+}
+
+// SignVoteExtension signs a vote extension made at the given height with the consensus key of
+// the given validator, the way CometBFT does
+func (n *Network) SignVoteExtension(v *Validator, voteExt []byte, height int64) []byte {
+	n.t.Helper()
+	var buf bytes.Buffer
+	err := protoio.NewDelimitedWriter(&buf).WriteMsg(&cmtproto.CanonicalVoteExtension{
+		Extension: voteExt,
+		Height:    height,
+		Round:     0,
+		ChainId:   ChainID,
+	})
+	require.NoError(n.t, err)
+	sig, err := v.PrivKey.Sign(buf.Bytes())
+	require.NoError(n.t, err)
+	return sig
+}
+
+// applyValidatorUpdates sets the power of the updated validators
+func (n *Network) applyValidatorUpdates(updates []abci.ValidatorUpdate) {
+	for _, update := range updates {
+		for _, v := range n.Validators {
+			if bytes.Equal(update.PubKey.GetEd25519(), v.PrivKey.PubKey().Bytes()) {
+				v.Power = update.Power
+			}
+		}
+	}
+}
+
+func isRejected(rejected []*Validator, v *Validator) bool {
+	for _, r := range rejected {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Context returns a context on the committed state of the given validator. Writes through it
+// land in the state the next height builds on.
+func (n *Network) Context(v *Validator) sdk.Context {
+	return v.App.NewUncachedContext(false, cmtproto.Header{ChainID: ChainID, Height: n.height, Time: n.Time(n.height)})
+}
+
+// Update applies the given change to the committed state of every validator, e.g. to set
+// params without a governance proposal
+func (n *Network) Update(update func(ctx sdk.Context, app *app.App)) {
+	for _, v := range n.Validators {
+		update(n.Context(v), v.App)
+	}
+}
+
+// SignTx signs a tx with the given msgs and memo with the key of an account in the committed
+// state
+func (n *Network) SignTx(priv cryptotypes.PrivKey, memo string, msgs ...sdk.Msg) []byte {
+	n.t.Helper()
+	v := n.Validators[0]
+	txConfig := v.App.GetTxConfig()
+	addr := sdk.AccAddress(priv.PubKey().Address())
+	acc := v.App.AccountKeeper.GetAccount(n.Context(v), addr)
+	require.NotNil(n.t, acc, "account %s does not exist", addr)
+
+	txBuilder := txConfig.NewTxBuilder()
+	require.NoError(n.t, txBuilder.SetMsgs(msgs...))
+	txBuilder.SetMemo(memo)
+	txBuilder.SetGasLimit(1_000_000)
+
+	signMode := signing.SignMode(txConfig.SignModeHandler().DefaultMode())
+	// the signer infos are part of the signed bytes, so they are set before signing
+	require.NoError(n.t, txBuilder.SetSignatures(signing.SignatureV2{
+		PubKey:   priv.PubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: acc.GetSequence(),
+	}))
+	sig, err := clienttx.SignWithPrivKey(context.Background(), signMode, authsigning.SignerData{
+		Address:       addr.String(),
+		ChainID:       ChainID,
+		AccountNumber: acc.GetAccountNumber(),
+		Sequence:      acc.GetSequence(),
+		PubKey:        priv.PubKey(),
+	}, txBuilder, priv, txConfig, acc.GetSequence())
+	require.NoError(n.t, err)
+	require.NoError(n.t, txBuilder.SetSignatures(sig))
+
+	bz, err := txConfig.TxEncoder()(txBuilder.GetTx())
+	require.NoError(n.t, err)
+	return bz
+}
+
+// Weights returns the weights stored by the given validator
+func (n *Network) Weights(v *Validator) map[string]int64 {
+	n.t.Helper()
+	weights, err := v.App.WeightsKeeper.GetWeights(n.Context(v))
+	require.NoError(n.t, err)
+	return weights
+}
+
+// RequireWeights requires every validator to store the given weights
+func (n *Network) RequireWeights(expected map[string]int64) {
+	n.t.Helper()
+	for i, v := range n.Validators {
+		require.Equal(n.t, expected, n.Weights(v), "validator %d stores other weights", i)
+	}
+}
+
+// Tokens returns the bond denom tokens worth the given consensus power
+func Tokens(power int64) math.Int {
+	return sdk.TokensFromConsensusPower(power, sdk.DefaultPowerReduction)
+}
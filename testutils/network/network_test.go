@@ -0,0 +1,86 @@
+package network_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/abci"
+	"github.com/ciprianmuja/weight-shift/testutils/network"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork(t *testing.T) {
+	n := network.New(t, network.DefaultConfig())
+	require.Len(t, n.Validators, 4)
+
+	// vote extensions are enabled from the second height
+	block := n.NextBlock()
+	require.Nil(t, block.VoteExtensions)
+	block = n.NextBlock()
+	require.Len(t, block.VoteExtensions, 4)
+	require.Empty(t, block.Rejected)
+	for _, bz := range block.VoteExtensions {
+		var voteExt abci.WeightedVotingPowerVoteExtension
+		require.NoError(t, json.Unmarshal(bz, &voteExt))
+		require.NotEmpty(t, voteExt.Weights)
+	}
+
+	// the weights reported at the second height are applied by every validator at the third
+	block = n.NextBlock()
+	require.True(t, hasEvent(block, proto.MessageName(&weightskeeper.EventWeightsUpdated{})))
+	weights := n.Weights(n.Validators[0])
+	// every validator is weighted under its consensus address
+	require.Len(t, weights, len(n.Validators))
+	for _, v := range n.Validators {
+		require.Contains(t, weights, string(v.ConsAddress()))
+	}
+	n.RequireWeights(weights)
+
+	// the genesis validators are not in a grace period, their metrics set their weights from
+	// the first epoch on
+	distinct := make(map[int64]bool)
+	for _, weight := range weights {
+		distinct[weight] = true
+	}
+	require.Greater(t, len(distinct), 1)
+}
+
+func TestNetworkValidatorUpdates(t *testing.T) {
+	n := network.New(t, network.DefaultConfig())
+	n.NextBlock()
+
+	val := n.Validators[0]
+	delegator := sdk.AccAddress(n.Delegator.PubKey().Address())
+	block := n.NextBlock(n.SignTx(n.Delegator, "", stakingtypes.NewMsgDelegate(
+		delegator.String(), val.OperatorAddress().String(), sdk.NewCoin(sdk.DefaultBondDenom, network.Tokens(2)))))
+	require.Len(t, block.Result.ValidatorUpdates, 1)
+	require.Equal(t, int64(3), block.Result.ValidatorUpdates[0].Power)
+
+	// like in CometBFT, the update takes effect two heights later
+	n.NextBlock()
+	require.Equal(t, int64(1), val.Power)
+	n.NextBlock()
+	require.Equal(t, int64(3), val.Power)
+}
+
+func TestNetworkDeterminism(t *testing.T) {
+	var appHashes [][]byte
+	for i := 0; i < 2; i++ {
+		n := network.New(t, network.DefaultConfig())
+		appHashes = append(appHashes, n.NextBlocks(4).Result.AppHash)
+	}
+	require.Equal(t, appHashes[0], appHashes[1])
+}
+
+func hasEvent(block *network.Block, eventType string) bool {
+	for _, event := range block.Result.Events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -1 +1,50 @@
 package testutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/ciprianmuja/weight-shift/provider"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+// NewMockRemoteSigner starts a remote signer serving the given keys by name, for testing the
+// provider's RemoteSigner. The caller closes the returned server.
+func NewMockRemoteSigner(keys map[string]cryptotypes.PrivKey) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pubkey", func(w http.ResponseWriter, r *http.Request) {
+		key, ok := keys[r.URL.Query().Get("key")]
+		if !ok {
+			http.Error(w, "unknown key", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, provider.RemotePubKeyResponse{PubKey: key.PubKey().Bytes()})
+	})
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		var req provider.RemoteSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key, ok := keys[req.Key]
+		if !ok {
+			http.Error(w, "unknown key", http.StatusNotFound)
+			return
+		}
+		sig, err := key.Sign(req.SignBytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, provider.RemoteSignResponse{Signature: sig})
+	})
+	return httptest.NewServer(mux)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,32 @@
+package weightskeeper
+
+import (
+	"context"
+	"errors"
+
+	"cosmossdk.io/collections"
+)
+
+// GetCommitment returns the commitment the given validator made in its last vote extension,
+// or nil if it made none.
+func (k WeightsKeeper) GetCommitment(ctx context.Context, consAddr []byte) ([]byte, error) {
+	commitment, err := k.Commitments.Get(ctx, consAddr)
+	if errors.Is(err, collections.ErrNotFound) {
+		return nil, nil
+	}
+	return commitment, err
+}
+
+// SetCommitments replaces the stored commitments with the given ones, keyed by consensus
+// address. Validators missing from the given set lose their previous commitment.
+func (k WeightsKeeper) SetCommitments(ctx context.Context, commitments map[string][]byte) error {
+	if err := k.Commitments.Clear(ctx, nil); err != nil {
+		return err
+	}
+	for consAddr, commitment := range commitments {
+		if err := k.Commitments.Set(ctx, []byte(consAddr), commitment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package weightskeeper_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cosmossdk.io/core/address"
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// setupKeeper creates a WeightsKeeper backed by in-memory ws and params stores. Any extra
+// store keys are mounted on the same context, an extra ws store key backing the keeper.
+func setupKeeper(t *testing.T, sk *mockStakingKeeper, extraKeys ...*storetypes.KVStoreKey) (sdk.Context, weightskeeper.WeightsKeeper) {
+	t.Helper()
+
+	wsKey := storetypes.NewKVStoreKey(weight_shift.StoreKey)
+	paramsKey := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	paramsTKey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+
+	keys := map[string]*storetypes.KVStoreKey{wsKey.Name(): wsKey, paramsKey.Name(): paramsKey}
+	for _, key := range extraKeys {
+		keys[key.Name()] = key
+	}
+	wsKey = keys[weight_shift.StoreKey]
+	ctx := testutil.DefaultContextWithKeys(
+		keys,
+		map[string]*storetypes.TransientStoreKey{paramsTKey.Name(): paramsTKey},
+		nil,
+	)
+
+	encCfg := testutils.MakeTestEncodingConfig()
+	paramsKeeper := paramskeeper.NewKeeper(encCfg.Marshaler, encCfg.Amino, paramsKey, paramsTKey)
+	keeper := weightskeeper.NewWeightsKeeper(
+		encCfg.Marshaler,
+		addresscodec.NewBech32Codec(sdk.Bech32MainPrefix),
+		runtime.NewKVStoreService(wsKey),
+		paramsKeeper.Subspace(weight_shift.ModuleName),
+		sk,
+		sk,
+	)
+
+	return ctx, keeper
+}
+
+// mockStakingKeeper holds a fixed set of bonded validators and the delegations of each
+// delegator. It also stands in for the slashing keeper, recording the validators it jails and
+// until when.
+type mockStakingKeeper struct {
+	validators  []stakingtypes.Validator
+	delegations map[string][]stakingtypes.Delegation
+	jailed      []sdk.ConsAddress
+	jailedUntil map[string]time.Time
+}
+
+func newValidator(addr sdk.ValAddress, tokens int64) stakingtypes.Validator {
+	val, err := stakingtypes.NewValidator(addr.String(), ed25519.GenPrivKey().PubKey(), stakingtypes.Description{})
+	if err != nil {
+		panic(err)
+	}
+	val.Status = stakingtypes.Bonded
+	val.Tokens = math.NewInt(tokens)
+	val.DelegatorShares = math.LegacyNewDec(tokens)
+	return val
+}
+
+func (*mockStakingKeeper) ValidatorAddressCodec() address.Codec {
+	return addresscodec.NewBech32Codec(sdk.Bech32PrefixValAddr)
+}
+
+func (m *mockStakingKeeper) IterateBondedValidatorsByPower(_ context.Context, fn func(int64, stakingtypes.ValidatorI) bool) error {
+	for i, val := range m.validators {
+		if fn(int64(i), val) {
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockStakingKeeper) TotalBondedTokens(context.Context) (math.Int, error) {
+	total := math.ZeroInt()
+	for _, val := range m.validators {
+		total = total.Add(val.Tokens)
+	}
+	return total, nil
+}
+
+func (m *mockStakingKeeper) IterateDelegations(_ context.Context, delegator sdk.AccAddress, fn func(int64, stakingtypes.DelegationI) bool) error {
+	for i, delegation := range m.delegations[delegator.String()] {
+		if fn(int64(i), delegation) {
+			break
+		}
+	}
+	return nil
+}
+
+func (m *mockStakingKeeper) GetValidator(_ context.Context, addr sdk.ValAddress) (stakingtypes.Validator, error) {
+	for _, val := range m.validators {
+		if val.OperatorAddress == addr.String() {
+			return val, nil
+		}
+	}
+	return stakingtypes.Validator{}, stakingtypes.ErrNoValidatorFound
+}
+
+func (m *mockStakingKeeper) GetValidatorByConsAddr(_ context.Context, consAddr sdk.ConsAddress) (stakingtypes.Validator, error) {
+	for _, val := range m.validators {
+		bz, err := val.GetConsAddr()
+		if err != nil {
+			return stakingtypes.Validator{}, err
+		}
+		if bytes.Equal(bz, consAddr) {
+			return val, nil
+		}
+	}
+	return stakingtypes.Validator{}, stakingtypes.ErrNoValidatorFound
+}
+
+func (m *mockStakingKeeper) Jail(_ context.Context, consAddr sdk.ConsAddress) error {
+	m.jailed = append(m.jailed, consAddr)
+	return nil
+}
+
+func (m *mockStakingKeeper) JailUntil(_ context.Context, consAddr sdk.ConsAddress, jailTime time.Time) error {
+	if m.jailedUntil == nil {
+		m.jailedUntil = make(map[string]time.Time)
+	}
+	m.jailedUntil[string(consAddr)] = jailTime
+	return nil
+}
+
+func consAddr(val stakingtypes.Validator) sdk.ConsAddress {
+	bz, err := val.GetConsAddr()
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
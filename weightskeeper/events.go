@@ -0,0 +1,21 @@
+package weightskeeper
+
+// ws module event types and attributes. The events of the weights and of the vote extensions
+// are typed, see events.proto.
+const (
+	EventTypeOutlierPenalty = "ws_outlier_penalty"
+	EventTypeFrontRun       = "ws_front_run"
+	EventTypeSealedBid      = "ws_sealed_bid"
+
+	AttributeKeyValidator     = "validator"
+	AttributeKeyEpoch         = "epoch"
+	AttributeKeyOutlierEpochs = "outlier_epochs"
+	AttributeKeyPenalty       = "penalty"
+	AttributeKeyJailed        = "jailed"
+	AttributeKeyName          = "name"
+	AttributeKeyFrontRunner   = "front_runner"
+	AttributeKeyVictim        = "victim"
+	AttributeKeyBidder        = "bidder"
+	AttributeKeyCommitment    = "commitment"
+	AttributeKeyExecuted      = "executed"
+)
@@ -0,0 +1,85 @@
+package weightskeeper
+
+import (
+	"context"
+	"sort"
+
+	collcodec "cosmossdk.io/collections/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// reasons a weight is adjusted for after aggregation
+const (
+	AdjustmentGracePeriod = "grace_period"
+	AdjustmentPenalty     = "penalty"
+)
+
+// ExplanationValue encodes explanations in the store
+var ExplanationValue collcodec.ValueCodec[Explanation] = jsonValue[Explanation]{name: "ws/Explanation"}
+
+// DeriveWeights turns the aggregated reported weights into the weights to apply: validators in
+// their grace period get the median weight and outlier penalties are deducted. How every weight
+// was derived is recorded along with the score of the aggregated metrics, keyed by metric then
+// validator, for the validator to look up. Validators are keyed by consensus address; the ones
+// staking does not know are dropped.
+func (k WeightsKeeper) DeriveWeights(ctx context.Context, reported map[string]int64, metrics map[string]ValidatorMap) (map[string]int64, error) {
+	reported, err := k.knownValidators(ctx, reported)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]ValidatorMap, len(metrics))
+	for metric, values := range metrics {
+		if known[metric], err = k.knownValidators(ctx, values); err != nil {
+			return nil, err
+		}
+	}
+	metrics = known
+
+	graced, err := k.ApplyGracePeriod(ctx, reported)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := k.ApplyPenalties(ctx, graced)
+	if err != nil {
+		return nil, err
+	}
+
+	metricWeights, scores := ScoreMetrics(metrics, k.GetParams(ctx).MetricCoefficients)
+	epoch := k.CurrentEpoch(ctx)
+	height := sdk.UnwrapSDKContext(ctx).BlockHeight()
+	for _, validator := range sortedKeys(weights) {
+		explanation := Explanation{
+			Epoch:          epoch,
+			Height:         height,
+			Metrics:        scores[validator],
+			MetricWeight:   metricWeights[validator],
+			ReportedWeight: reported[validator],
+			Weight:         weights[validator],
+		}
+		for _, step := range []struct {
+			reason   string
+			from, to int64
+		}{
+			{AdjustmentGracePeriod, reported[validator], graced[validator]},
+			{AdjustmentPenalty, graced[validator], weights[validator]},
+		} {
+			if step.from != step.to {
+				explanation.Adjustments = append(explanation.Adjustments, WeightAdjustment{Reason: step.reason, From: step.from, To: step.to})
+			}
+		}
+		if err := k.Explanations.Set(ctx, sdk.ConsAddress(validator), explanation); err != nil {
+			return nil, err
+		}
+	}
+	return weights, nil
+}
+
+// sortedKeys returns the keys of the given map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
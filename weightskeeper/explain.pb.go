@@ -0,0 +1,1186 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: ws/v1/explain.proto
+
+package weightskeeper
+
+import (
+	cosmossdk_io_math "cosmossdk.io/math"
+	fmt "fmt"
+	_ "github.com/cosmos/cosmos-proto"
+	_ "github.com/cosmos/gogoproto/gogoproto"
+	proto "github.com/cosmos/gogoproto/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// Explanation is how the weight of a validator was derived when the weights were last
+// aggregated
+type Explanation struct {
+	Epoch  int64 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Height int64 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	// metrics holds the score of each metric, from the stake weighted median of the raw values
+	// the validators reported
+	Metrics []MetricScore `protobuf:"bytes,3,rep,name=metrics,proto3" json:"metrics"`
+	// metric_weight is the weight the metrics add up to
+	MetricWeight int64 `protobuf:"varint,4,opt,name=metric_weight,json=metricWeight,proto3" json:"metric_weight,omitempty"`
+	// reported_weight is the stake weighted median of the weights the validators reported
+	ReportedWeight int64 `protobuf:"varint,5,opt,name=reported_weight,json=reportedWeight,proto3" json:"reported_weight,omitempty"`
+	// adjustments lists, in order, the grace period and penalty that changed the reported weight
+	Adjustments []WeightAdjustment `protobuf:"bytes,6,rep,name=adjustments,proto3" json:"adjustments"`
+	// weight is the weight the validator was given
+	Weight int64 `protobuf:"varint,7,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (m *Explanation) Reset()         { *m = Explanation{} }
+func (m *Explanation) String() string { return proto.CompactTextString(m) }
+func (*Explanation) ProtoMessage()    {}
+func (*Explanation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ac22ac0f16874751, []int{0}
+}
+func (m *Explanation) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Explanation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Explanation.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Explanation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Explanation.Merge(m, src)
+}
+func (m *Explanation) XXX_Size() int {
+	return m.Size()
+}
+func (m *Explanation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Explanation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Explanation proto.InternalMessageInfo
+
+func (m *Explanation) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Explanation) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Explanation) GetMetrics() []MetricScore {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+func (m *Explanation) GetMetricWeight() int64 {
+	if m != nil {
+		return m.MetricWeight
+	}
+	return 0
+}
+
+func (m *Explanation) GetReportedWeight() int64 {
+	if m != nil {
+		return m.ReportedWeight
+	}
+	return 0
+}
+
+func (m *Explanation) GetAdjustments() []WeightAdjustment {
+	if m != nil {
+		return m.Adjustments
+	}
+	return nil
+}
+
+func (m *Explanation) GetWeight() int64 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+// MetricScore is the part a metric plays in the weight of a validator
+type MetricScore struct {
+	Metric string `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	// raw is the value of the metric for the validator
+	Raw int64 `protobuf:"varint,2,opt,name=raw,proto3" json:"raw,omitempty"`
+	// normalized is the raw value over the highest raw value of the set, in [0, 1]
+	Normalized  cosmossdk_io_math.LegacyDec `protobuf:"bytes,3,opt,name=normalized,proto3,customtype=cosmossdk.io/math.LegacyDec" json:"normalized"`
+	Coefficient cosmossdk_io_math.LegacyDec `protobuf:"bytes,4,opt,name=coefficient,proto3,customtype=cosmossdk.io/math.LegacyDec" json:"coefficient"`
+	// contribution is the weight the metric adds, the normalized value times the coefficient
+	// times MaxWeight
+	Contribution cosmossdk_io_math.LegacyDec `protobuf:"bytes,5,opt,name=contribution,proto3,customtype=cosmossdk.io/math.LegacyDec" json:"contribution"`
+}
+
+func (m *MetricScore) Reset()         { *m = MetricScore{} }
+func (m *MetricScore) String() string { return proto.CompactTextString(m) }
+func (*MetricScore) ProtoMessage()    {}
+func (*MetricScore) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ac22ac0f16874751, []int{1}
+}
+func (m *MetricScore) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MetricScore) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MetricScore.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MetricScore) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricScore.Merge(m, src)
+}
+func (m *MetricScore) XXX_Size() int {
+	return m.Size()
+}
+func (m *MetricScore) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricScore.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricScore proto.InternalMessageInfo
+
+func (m *MetricScore) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+func (m *MetricScore) GetRaw() int64 {
+	if m != nil {
+		return m.Raw
+	}
+	return 0
+}
+
+// WeightAdjustment is a step of the pipeline that changed the aggregated weight of a validator
+type WeightAdjustment struct {
+	// reason is AdjustmentGracePeriod or AdjustmentPenalty
+	Reason string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	From   int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	To     int64  `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (m *WeightAdjustment) Reset()         { *m = WeightAdjustment{} }
+func (m *WeightAdjustment) String() string { return proto.CompactTextString(m) }
+func (*WeightAdjustment) ProtoMessage()    {}
+func (*WeightAdjustment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ac22ac0f16874751, []int{2}
+}
+func (m *WeightAdjustment) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WeightAdjustment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WeightAdjustment.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WeightAdjustment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WeightAdjustment.Merge(m, src)
+}
+func (m *WeightAdjustment) XXX_Size() int {
+	return m.Size()
+}
+func (m *WeightAdjustment) XXX_DiscardUnknown() {
+	xxx_messageInfo_WeightAdjustment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WeightAdjustment proto.InternalMessageInfo
+
+func (m *WeightAdjustment) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *WeightAdjustment) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *WeightAdjustment) GetTo() int64 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Explanation)(nil), "ws.v1.Explanation")
+	proto.RegisterType((*MetricScore)(nil), "ws.v1.MetricScore")
+	proto.RegisterType((*WeightAdjustment)(nil), "ws.v1.WeightAdjustment")
+}
+
+func init() { proto.RegisterFile("ws/v1/explain.proto", fileDescriptor_ac22ac0f16874751) }
+
+var fileDescriptor_ac22ac0f16874751 = []byte{
+	// 470 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x52, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0x8d, 0xed, 0x24, 0xd5, 0x37, 0xee, 0x57, 0xaa, 0xa1, 0x02, 0x53, 0x24, 0xb7, 0x0a, 0x0b,
+	0xba, 0xa9, 0xad, 0x94, 0x07, 0x40, 0x44, 0x65, 0xc5, 0x8f, 0x84, 0x2b, 0x84, 0xc4, 0xa6, 0x9a,
+	0x4c, 0x6e, 0xec, 0x69, 0x6b, 0x5f, 0x6b, 0x66, 0xd2, 0x00, 0x4f, 0xc1, 0x8a, 0x27, 0x41, 0x3c,
+	0x43, 0x97, 0x15, 0x2b, 0xc4, 0xa2, 0x42, 0xc9, 0x8b, 0xa0, 0xf9, 0x09, 0x0a, 0x2c, 0xbb, 0xbb,
+	0xe7, 0xde, 0xe3, 0x33, 0xe7, 0x9e, 0x6b, 0x72, 0x77, 0xae, 0xf2, 0xcb, 0x61, 0x0e, 0x1f, 0xda,
+	0x0b, 0x26, 0x9a, 0xac, 0x95, 0xa8, 0x91, 0xf6, 0xe6, 0x2a, 0xbb, 0x1c, 0xee, 0xee, 0x94, 0x58,
+	0xa2, 0xed, 0xe4, 0xa6, 0x72, 0xc3, 0xdd, 0x07, 0x1c, 0x55, 0x8d, 0xea, 0xd4, 0x0d, 0x1c, 0x70,
+	0xa3, 0xc1, 0x97, 0x90, 0xc4, 0xcf, 0x8d, 0x52, 0xc3, 0xb4, 0xc0, 0x86, 0xee, 0x90, 0x1e, 0xb4,
+	0xc8, 0xab, 0x24, 0xd8, 0x0f, 0x0e, 0xa2, 0xc2, 0x01, 0x7a, 0x8f, 0xf4, 0x2b, 0x10, 0x65, 0xa5,
+	0x93, 0xd0, 0xb6, 0x3d, 0xa2, 0x47, 0x64, 0xa3, 0x06, 0x2d, 0x05, 0x57, 0x49, 0xb4, 0x1f, 0x1d,
+	0xc4, 0x47, 0x34, 0xb3, 0x3e, 0xb2, 0x57, 0xb6, 0x7b, 0xc2, 0x51, 0xc2, 0xa8, 0x7b, 0x75, 0xb3,
+	0xd7, 0x29, 0x56, 0x44, 0xfa, 0x88, 0xfc, 0xef, 0xca, 0xd3, 0xb9, 0x93, 0xec, 0x5a, 0xc9, 0x4d,
+	0xd7, 0x7c, 0xe7, 0x84, 0x1f, 0x93, 0x3b, 0x12, 0x5a, 0x94, 0x1a, 0x26, 0x2b, 0x5a, 0xcf, 0xd2,
+	0xb6, 0x56, 0x6d, 0x4f, 0x7c, 0x4a, 0x62, 0x36, 0x39, 0x9b, 0x29, 0x5d, 0x43, 0xa3, 0x55, 0xd2,
+	0xb7, 0x2e, 0xee, 0x7b, 0x17, 0x8e, 0xf3, 0xec, 0xcf, 0xdc, 0x5b, 0x59, 0xff, 0xc2, 0xac, 0xe6,
+	0x1f, 0xd8, 0x70, 0xab, 0x39, 0x34, 0xf8, 0x16, 0x92, 0x78, 0x6d, 0x0b, 0xc3, 0x73, 0x0e, 0x6d,
+	0x32, 0xff, 0x15, 0x1e, 0xd1, 0x6d, 0x12, 0x49, 0x36, 0xf7, 0xb9, 0x98, 0x92, 0xbe, 0x21, 0xa4,
+	0x41, 0x59, 0xb3, 0x0b, 0xf1, 0x09, 0x26, 0x49, 0x64, 0xd8, 0xa3, 0xa1, 0x79, 0xf8, 0xe7, 0xcd,
+	0xde, 0x43, 0x17, 0xbe, 0x9a, 0x9c, 0x67, 0x02, 0xf3, 0x9a, 0xe9, 0x2a, 0x7b, 0x09, 0x25, 0xe3,
+	0x1f, 0x8f, 0x81, 0x7f, 0xff, 0x7a, 0x48, 0xfc, 0x6d, 0x8e, 0x81, 0x17, 0x6b, 0x22, 0xf4, 0x84,
+	0xc4, 0x1c, 0x61, 0x3a, 0x15, 0x5c, 0x40, 0xe3, 0x12, 0xbb, 0x95, 0xe6, 0xba, 0x0a, 0x7d, 0x4b,
+	0x36, 0x39, 0x36, 0x5a, 0x8a, 0xf1, 0xcc, 0x9c, 0x3e, 0xe9, 0xdd, 0x56, 0xf5, 0x2f, 0x99, 0xc1,
+	0x6b, 0xb2, 0xfd, 0x6f, 0xee, 0x26, 0x3c, 0x09, 0x4c, 0x61, 0xb3, 0x0a, 0xcf, 0x21, 0x4a, 0x49,
+	0x77, 0x2a, 0xb1, 0xf6, 0xe9, 0xd9, 0x9a, 0x6e, 0x91, 0x50, 0xa3, 0x8d, 0x2d, 0x2a, 0x42, 0x8d,
+	0xa3, 0x17, 0x57, 0x8b, 0x34, 0xb8, 0x5e, 0xa4, 0xc1, 0xaf, 0x45, 0x1a, 0x7c, 0x5e, 0xa6, 0x9d,
+	0xeb, 0x65, 0xda, 0xf9, 0xb1, 0x4c, 0x3b, 0xef, 0x87, 0xa5, 0xd0, 0xd5, 0x6c, 0x9c, 0x71, 0xac,
+	0x73, 0x2e, 0x5a, 0x29, 0x58, 0x53, 0xcf, 0xce, 0x58, 0xee, 0x2e, 0x78, 0xa8, 0x2a, 0x31, 0xd5,
+	0x1e, 0xa8, 0x73, 0x80, 0x16, 0xe4, 0xb8, 0x6f, 0xff, 0xfa, 0x27, 0xbf, 0x07, 0x00, 0xfb, 0xdb,
+	0x58, 0x5e, 0x44, 0x03, 0x00, 0x00,
+}
+
+func (m *Explanation) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Explanation) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Explanation) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Weight != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.Weight))
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.Adjustments) > 0 {
+		for iNdEx := len(m.Adjustments) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Adjustments[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintExplain(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.ReportedWeight != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.ReportedWeight))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MetricWeight != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.MetricWeight))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Metrics) > 0 {
+		for iNdEx := len(m.Metrics) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metrics[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintExplain(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.Height != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.Height))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Epoch != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.Epoch))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricScore) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricScore) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricScore) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size := m.Contribution.Size()
+		i -= size
+		if _, err := m.Contribution.MarshalTo(dAtA[i:]); err != nil {
+			return 0, err
+		}
+		i = encodeVarintExplain(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x2a
+	{
+		size := m.Coefficient.Size()
+		i -= size
+		if _, err := m.Coefficient.MarshalTo(dAtA[i:]); err != nil {
+			return 0, err
+		}
+		i = encodeVarintExplain(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
+	{
+		size := m.Normalized.Size()
+		i -= size
+		if _, err := m.Normalized.MarshalTo(dAtA[i:]); err != nil {
+			return 0, err
+		}
+		i = encodeVarintExplain(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x1a
+	if m.Raw != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.Raw))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Metric) > 0 {
+		i -= len(m.Metric)
+		copy(dAtA[i:], m.Metric)
+		i = encodeVarintExplain(dAtA, i, uint64(len(m.Metric)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *WeightAdjustment) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WeightAdjustment) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *WeightAdjustment) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.To != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.To))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.From != 0 {
+		i = encodeVarintExplain(dAtA, i, uint64(m.From))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Reason) > 0 {
+		i -= len(m.Reason)
+		copy(dAtA[i:], m.Reason)
+		i = encodeVarintExplain(dAtA, i, uint64(len(m.Reason)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintExplain(dAtA []byte, offset int, v uint64) int {
+	offset -= sovExplain(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Explanation) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Epoch != 0 {
+		n += 1 + sovExplain(uint64(m.Epoch))
+	}
+	if m.Height != 0 {
+		n += 1 + sovExplain(uint64(m.Height))
+	}
+	if len(m.Metrics) > 0 {
+		for _, e := range m.Metrics {
+			l = e.Size()
+			n += 1 + l + sovExplain(uint64(l))
+		}
+	}
+	if m.MetricWeight != 0 {
+		n += 1 + sovExplain(uint64(m.MetricWeight))
+	}
+	if m.ReportedWeight != 0 {
+		n += 1 + sovExplain(uint64(m.ReportedWeight))
+	}
+	if len(m.Adjustments) > 0 {
+		for _, e := range m.Adjustments {
+			l = e.Size()
+			n += 1 + l + sovExplain(uint64(l))
+		}
+	}
+	if m.Weight != 0 {
+		n += 1 + sovExplain(uint64(m.Weight))
+	}
+	return n
+}
+
+func (m *MetricScore) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Metric)
+	if l > 0 {
+		n += 1 + l + sovExplain(uint64(l))
+	}
+	if m.Raw != 0 {
+		n += 1 + sovExplain(uint64(m.Raw))
+	}
+	l = m.Normalized.Size()
+	n += 1 + l + sovExplain(uint64(l))
+	l = m.Coefficient.Size()
+	n += 1 + l + sovExplain(uint64(l))
+	l = m.Contribution.Size()
+	n += 1 + l + sovExplain(uint64(l))
+	return n
+}
+
+func (m *WeightAdjustment) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Reason)
+	if l > 0 {
+		n += 1 + l + sovExplain(uint64(l))
+	}
+	if m.From != 0 {
+		n += 1 + sovExplain(uint64(m.From))
+	}
+	if m.To != 0 {
+		n += 1 + sovExplain(uint64(m.To))
+	}
+	return n
+}
+
+func sovExplain(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozExplain(x uint64) (n int) {
+	return sovExplain(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Explanation) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowExplain
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Explanation: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Explanation: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
+			}
+			m.Epoch = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Epoch |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Height", wireType)
+			}
+			m.Height = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Height |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metrics", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metrics = append(m.Metrics, MetricScore{})
+			if err := m.Metrics[len(m.Metrics)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MetricWeight", wireType)
+			}
+			m.MetricWeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MetricWeight |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReportedWeight", wireType)
+			}
+			m.ReportedWeight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReportedWeight |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Adjustments", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Adjustments = append(m.Adjustments, WeightAdjustment{})
+			if err := m.Adjustments[len(m.Adjustments)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Weight", wireType)
+			}
+			m.Weight = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Weight |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipExplain(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricScore) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowExplain
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricScore: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricScore: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Raw", wireType)
+			}
+			m.Raw = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Raw |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Normalized", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Normalized.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Coefficient", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Coefficient.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Contribution", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Contribution.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipExplain(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WeightAdjustment) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowExplain
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WeightAdjustment: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WeightAdjustment: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthExplain
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reason = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
+			}
+			m.From = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.From |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field To", wireType)
+			}
+			m.To = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.To |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipExplain(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthExplain
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipExplain(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowExplain
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowExplain
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthExplain
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupExplain
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthExplain
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthExplain        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowExplain          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupExplain = fmt.Errorf("proto: unexpected end of group")
+)
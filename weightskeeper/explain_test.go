@@ -0,0 +1,157 @@
+package weightskeeper_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScoreMetrics(t *testing.T) {
+	metrics := map[string]weightskeeper.ValidatorMap{
+		weightskeeper.MetricUptime:        {"val1": 100, "val2": 50},
+		weightskeeper.MetricGovernance:    {"val1": 80, "val2": 80},
+		weightskeeper.MetricContributions: {"val2": 10},
+	}
+	weights, scores := weightskeeper.ScoreMetrics(metrics, weightskeeper.DefaultMetricCoefficients())
+
+	// val1 tops uptime and governance, 0.4 + 0.4 of MaxWeight
+	require.Equal(t, int64(44), weights["val1"])
+	// val2 gets half of the uptime share, all of the governance and contributions shares
+	require.Equal(t, int64(44), weights["val2"])
+
+	require.Len(t, scores["val2"], 3)
+	uptime := scores["val2"][0]
+	require.Equal(t, weightskeeper.MetricUptime, uptime.Metric)
+	require.Equal(t, int64(50), uptime.Raw)
+	require.Equal(t, math.LegacyNewDecWithPrec(5, 1), uptime.Normalized)
+	require.Equal(t, math.LegacyNewDecWithPrec(4, 1), uptime.Coefficient)
+	require.Equal(t, math.LegacyNewDec(11), uptime.Contribution)
+
+	// a validator missing from a metric scores zero on it
+	contributions := scores["val1"][2]
+	require.Equal(t, int64(0), contributions.Raw)
+	require.True(t, contributions.Contribution.IsZero())
+
+	require.NoError(t, weightskeeper.ValidateMetrics(map[string]weightskeeper.ValidatorMap{weightskeeper.MetricUptime: {"val1": 0}}))
+	require.Error(t, weightskeeper.ValidateMetrics(map[string]weightskeeper.ValidatorMap{"stake": {"val1": 1}}))
+	require.Error(t, weightskeeper.ValidateMetrics(map[string]weightskeeper.ValidatorMap{weightskeeper.MetricUptime: {"val1": -1}}))
+
+	// governance can make a single metric decide the weights
+	weights, _ = weightskeeper.ScoreMetrics(metrics, []weightskeeper.MetricCoefficient{
+		{Metric: weightskeeper.MetricUptime, Coefficient: math.LegacyOneDec()},
+	})
+	require.Equal(t, map[string]int64{"val1": weightskeeper.MaxWeight, "val2": weightskeeper.MaxWeight / 2}, weights)
+}
+
+func TestValidateMetricCoefficients(t *testing.T) {
+	require.NoError(t, weightskeeper.ValidateMetricCoefficients(weightskeeper.DefaultMetricCoefficients()))
+
+	half := math.LegacyNewDecWithPrec(5, 1)
+	for _, coefficients := range [][]weightskeeper.MetricCoefficient{
+		nil,
+		{{Metric: weightskeeper.MetricUptime, Coefficient: half}},
+		{{Metric: "stake", Coefficient: math.LegacyOneDec()}},
+		{{Metric: weightskeeper.MetricUptime, Coefficient: half}, {Metric: weightskeeper.MetricUptime, Coefficient: half}},
+		{{Metric: weightskeeper.MetricUptime, Coefficient: math.LegacyNewDec(2)}, {Metric: weightskeeper.MetricGovernance, Coefficient: math.LegacyNewDec(-1)}},
+		{{Metric: weightskeeper.MetricUptime}},
+	} {
+		require.Error(t, weightskeeper.ValidateMetricCoefficients(coefficients))
+	}
+
+	params := weightskeeper.DefaultParams()
+	params.MetricCoefficients = []weightskeeper.MetricCoefficient{{Metric: weightskeeper.MetricUptime, Coefficient: half}}
+	require.Error(t, params.Validate())
+
+	// the simulation only generates valid coefficients
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, weightskeeper.RandomParams(r).Validate())
+	}
+}
+
+func TestDeriveWeights(t *testing.T) {
+	newcomer := newValidator(sdk.ValAddress("newcomer____________"), 100_000_000)
+	penalized := newValidator(sdk.ValAddress("penalized___________"), 100_000_000)
+	established := newValidator(sdk.ValAddress("established_________"), 200_000_000)
+	sk := &mockStakingKeeper{validators: []stakingtypes.Validator{newcomer, penalized, established}}
+	ctx, keeper := setupKeeper(t, sk)
+	params := weightskeeper.DefaultParams()
+	params.EpochLength = 10
+	params.GracePeriodEpochs = 2
+	keeper.SetParams(ctx, params)
+
+	require.NoError(t, keeper.AfterValidatorBonded(ctx.WithBlockHeight(100), consAddr(newcomer), nil))
+	require.NoError(t, keeper.Penalties.Set(ctx, consAddr(penalized), 20))
+
+	// values reported for a validator staking does not know are dropped
+	ctx = ctx.WithBlockHeight(105).WithEventManager(sdk.NewEventManager())
+	reported := map[string]int64{
+		string(consAddr(newcomer)):    5,
+		string(consAddr(penalized)):   30,
+		string(consAddr(established)): weightskeeper.MaxWeight,
+		"val1":                        20,
+	}
+	metrics := map[string]weightskeeper.ValidatorMap{
+		weightskeeper.MetricUptime: {string(consAddr(established)): 100, string(consAddr(penalized)): 50, "val1": 200},
+	}
+	weights, err := keeper.DeriveWeights(ctx, reported, metrics)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{
+		string(consAddr(newcomer)):    30,
+		string(consAddr(penalized)):   10,
+		string(consAddr(established)): weightskeeper.MaxWeight,
+	}, weights)
+	has, err := keeper.Explanations.Has(ctx, sdk.ConsAddress("val1"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	explanation, err := keeper.Explanations.Get(ctx, consAddr(newcomer))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), explanation.Epoch)
+	require.Equal(t, int64(105), explanation.Height)
+	require.Equal(t, int64(5), explanation.ReportedWeight)
+	require.Equal(t, []weightskeeper.WeightAdjustment{{Reason: weightskeeper.AdjustmentGracePeriod, From: 5, To: 30}}, explanation.Adjustments)
+	require.Equal(t, int64(30), explanation.Weight)
+
+	explanation, err = keeper.Explanations.Get(ctx, consAddr(penalized))
+	require.NoError(t, err)
+	require.Equal(t, []weightskeeper.WeightAdjustment{{Reason: weightskeeper.AdjustmentPenalty, From: 30, To: 10}}, explanation.Adjustments)
+	require.Equal(t, int64(11), explanation.MetricWeight)
+	require.Len(t, explanation.Metrics, len(weightskeeper.DefaultMetricCoefficients()))
+	require.Equal(t, int64(50), explanation.Metrics[0].Raw)
+
+	explanation, err = keeper.Explanations.Get(ctx, consAddr(established))
+	require.NoError(t, err)
+	require.Empty(t, explanation.Adjustments)
+	require.Equal(t, weightskeeper.MaxWeight, explanation.Weight)
+	require.Equal(t, int64(22), explanation.MetricWeight)
+
+	// the query returns the explanation along with the power the weight gives
+	require.NoError(t, keeper.SetWeights(ctx, weights))
+	res, err := weightskeeper.NewQueryServer(keeper).Explain(ctx, &weightskeeper.QueryExplainRequest{Validator: established.OperatorAddress})
+	require.NoError(t, err)
+	require.Equal(t, explanation, res.Explanation)
+	require.Equal(t, consAddr(established).String(), res.ConsensusAddress)
+	require.Equal(t, int64(200), res.Power)
+	require.Equal(t, int64(310), res.EffectivePower)
+
+	// the messages survive the wire encoding of the query
+	bz, err := res.Marshal()
+	require.NoError(t, err)
+	var decoded weightskeeper.QueryExplainResponse
+	require.NoError(t, decoded.Unmarshal(bz))
+	require.Equal(t, *res, decoded)
+
+	_, err = weightskeeper.NewQueryServer(keeper).Explain(ctx, &weightskeeper.QueryExplainRequest{Validator: "val1"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	unknown := sdk.ValAddress("unknown_____________").String()
+	_, err = weightskeeper.NewQueryServer(keeper).Explain(ctx, &weightskeeper.QueryExplainRequest{Validator: unknown})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
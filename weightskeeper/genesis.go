@@ -0,0 +1,297 @@
+package weightskeeper
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// GenesisState defines the ws module's genesis state
+type GenesisState struct {
+	Params Params `json:"params"`
+	// Weights holds the weight record of every validator
+	Weights []GenesisWeight `json:"weights,omitempty"`
+	// BondingHeights holds the height every validator first bonded at
+	BondingHeights []ValidatorValue `json:"bonding_heights,omitempty"`
+	// Deviations holds the deviations recorded for the epoch that was open at export
+	Deviations []GenesisDeviation `json:"deviations,omitempty"`
+	// OutlierStreaks holds the number of consecutive outlier epochs of every validator
+	OutlierStreaks []ValidatorValue `json:"outlier_streaks,omitempty"`
+	// Penalties holds the weight deducted from every penalized validator
+	Penalties []ValidatorValue `json:"penalties,omitempty"`
+}
+
+// GenesisWeight is the weight record of a validator in the genesis state. Validators are
+// identified by their consensus address throughout the genesis state.
+type GenesisWeight struct {
+	Validator string       `json:"validator"`
+	Record    WeightRecord `json:"record"`
+}
+
+// GenesisDeviation is the deviation of a validator in an epoch in the genesis state
+type GenesisDeviation struct {
+	Epoch     int64  `json:"epoch"`
+	Validator string `json:"validator"`
+	Deviation int64  `json:"deviation"`
+}
+
+// ValidatorValue is a value kept per validator in the genesis state
+type ValidatorValue struct {
+	Validator string `json:"validator"`
+	Value     int64  `json:"value"`
+}
+
+// DefaultGenesisState returns the default ws genesis state
+func DefaultGenesisState() *GenesisState {
+	return &GenesisState{Params: DefaultParams()}
+}
+
+// Validate performs a basic validation of the genesis state
+func (gs GenesisState) Validate() error {
+	if err := gs.Params.Validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, weight := range gs.Weights {
+		if err := validateGenesisValidator("weights", weight.Validator, seen); err != nil {
+			return err
+		}
+		if weight.Record.Weight < 0 || weight.Record.Weight > MaxWeight {
+			return fmt.Errorf("weight %d of %s is out of range [0, %d]", weight.Record.Weight, weight.Validator, MaxWeight)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for _, deviation := range gs.Deviations {
+		key := fmt.Sprintf("%d/%s", deviation.Epoch, deviation.Validator)
+		if _, err := sdk.ConsAddressFromBech32(deviation.Validator); err != nil || seen[key] {
+			return fmt.Errorf("invalid or duplicate deviation of %q in epoch %d", deviation.Validator, deviation.Epoch)
+		}
+		seen[key] = true
+		if deviation.Deviation < 0 {
+			return fmt.Errorf("negative deviation of %s in epoch %d", deviation.Validator, deviation.Epoch)
+		}
+	}
+
+	for _, values := range []struct {
+		name   string
+		values []ValidatorValue
+	}{
+		{"bonding heights", gs.BondingHeights},
+		{"outlier streaks", gs.OutlierStreaks},
+		{"penalties", gs.Penalties},
+	} {
+		seen = make(map[string]bool)
+		for _, value := range values.values {
+			if err := validateGenesisValidator(values.name, value.Validator, seen); err != nil {
+				return err
+			}
+		}
+	}
+	for _, streak := range gs.OutlierStreaks {
+		if streak.Value < 0 {
+			return fmt.Errorf("negative outlier streak of %s", streak.Validator)
+		}
+	}
+	for _, penalty := range gs.Penalties {
+		if penalty.Value < 0 {
+			return fmt.Errorf("negative penalty of %s", penalty.Validator)
+		}
+	}
+	return nil
+}
+
+func validateGenesisValidator(name, validator string, seen map[string]bool) error {
+	if _, err := sdk.ConsAddressFromBech32(validator); err != nil {
+		return fmt.Errorf("%s entry with invalid validator %q: %w", name, validator, err)
+	}
+	if seen[validator] {
+		return fmt.Errorf("duplicate %s entry for %s", name, validator)
+	}
+	seen[validator] = true
+	return nil
+}
+
+// InitGenesis initializes the ws module's state from the given genesis state
+func (k WeightsKeeper) InitGenesis(ctx context.Context, gs GenesisState) error {
+	if err := gs.Validate(); err != nil {
+		return err
+	}
+	k.SetParams(ctx, gs.Params)
+
+	// the validators staking created in genesis, ahead of the ws genesis, start at the base
+	// weight of the ws genesis unless it gives them a weight
+	var created []sdk.ConsAddress
+	err := k.Weights.Walk(ctx, nil, func(validator sdk.ConsAddress, _ WeightRecord) (bool, error) {
+		created = append(created, validator)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, validator := range created {
+		if err := k.setWeight(ctx, validator, gs.Params.BaseWeight); err != nil {
+			return err
+		}
+	}
+
+	for _, weight := range gs.Weights {
+		consAddr, err := sdk.ConsAddressFromBech32(weight.Validator)
+		if err != nil {
+			return err
+		}
+		if err := k.Weights.Set(ctx, consAddr, weight.Record); err != nil {
+			return err
+		}
+	}
+	for _, deviation := range gs.Deviations {
+		consAddr, err := sdk.ConsAddressFromBech32(deviation.Validator)
+		if err != nil {
+			return err
+		}
+		if err := k.Deviations.Set(ctx, collections.Join(deviation.Epoch, consAddr), deviation.Deviation); err != nil {
+			return err
+		}
+	}
+	// the validators staking bonded in genesis are established from the start: their bonding
+	// heights, recorded by the staking hooks ahead of the ws genesis, are dropped so they are
+	// not taken for newcomers. The bonding heights of an exported genesis are kept.
+	if err := k.BondingHeights.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := importValidatorValues(ctx, k.BondingHeights, gs.BondingHeights); err != nil {
+		return err
+	}
+	if err := importValidatorValues(ctx, k.OutlierStreaks, gs.OutlierStreaks); err != nil {
+		return err
+	}
+	return importValidatorValues(ctx, k.Penalties, gs.Penalties)
+}
+
+func importValidatorValues(ctx context.Context, m collections.Map[sdk.ConsAddress, int64], values []ValidatorValue) error {
+	for _, value := range values {
+		consAddr, err := sdk.ConsAddressFromBech32(value.Validator)
+		if err != nil {
+			return err
+		}
+		if err := m.Set(ctx, consAddr, value.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportGenesis returns the ws module's genesis state. The commit-reveal commitments and the
+// sealed bid commitments only live for a few heights and are not exported, nor are the
+// explanations of the weights, which the next aggregation records again.
+func (k WeightsKeeper) ExportGenesis(ctx context.Context) (*GenesisState, error) {
+	gs := &GenesisState{Params: k.GetParams(ctx)}
+
+	err := k.Weights.Walk(ctx, nil, func(validator sdk.ConsAddress, record WeightRecord) (bool, error) {
+		gs.Weights = append(gs.Weights, GenesisWeight{Validator: validator.String(), Record: record})
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = k.Deviations.Walk(ctx, nil, func(key collections.Pair[int64, sdk.ConsAddress], deviation int64) (bool, error) {
+		gs.Deviations = append(gs.Deviations, GenesisDeviation{Epoch: key.K1(), Validator: key.K2().String(), Deviation: deviation})
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if gs.BondingHeights, err = exportValidatorValues(ctx, k.BondingHeights); err != nil {
+		return nil, err
+	}
+	if gs.OutlierStreaks, err = exportValidatorValues(ctx, k.OutlierStreaks); err != nil {
+		return nil, err
+	}
+	if gs.Penalties, err = exportValidatorValues(ctx, k.Penalties); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+func exportValidatorValues(ctx context.Context, m collections.Map[sdk.ConsAddress, int64]) ([]ValidatorValue, error) {
+	var values []ValidatorValue
+	err := m.Walk(ctx, nil, func(validator sdk.ConsAddress, value int64) (bool, error) {
+		values = append(values, ValidatorValue{Validator: validator.String(), Value: value})
+		return false, nil
+	})
+	return values, err
+}
+
+// PrepForZeroHeightGenesis rebases the state tied to heights and epochs onto a chain restarting
+// from height zero, i.e. from epoch zero:
+//
+//   - weight records keep their weight and are recorded as set at genesis
+//   - bonding heights are moved back so that as many epochs have elapsed since bonding at
+//     epoch zero as at the export, which keeps grace periods running where they stood
+//   - the deviations of the open epoch carry over to epoch zero, older ones are dropped
+//   - commit-reveal and sealed bid commitments are dropped, nobody can reveal them after the
+//     restart
+//   - the explanations of the weights are dropped, they refer to heights of the old chain
+//
+// Outlier streaks and penalties are not tied to heights and carry over as they are.
+func (k WeightsKeeper) PrepForZeroHeightGenesis(ctx context.Context) error {
+	params := k.GetParams(ctx)
+	epoch := k.CurrentEpoch(ctx)
+
+	weights := make(map[string]int64)
+	err := k.Weights.Walk(ctx, nil, func(validator sdk.ConsAddress, record WeightRecord) (bool, error) {
+		weights[string(validator)] = record.Weight
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, validator := range sortedKeys(weights) {
+		if err := k.Weights.Set(ctx, sdk.ConsAddress(validator), WeightRecord{Weight: weights[validator]}); err != nil {
+			return err
+		}
+	}
+
+	bondingHeights := make(map[string]int64)
+	err = k.BondingHeights.Walk(ctx, nil, func(validator sdk.ConsAddress, height int64) (bool, error) {
+		bondingHeights[string(validator)] = height
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, validator := range sortedKeys(bondingHeights) {
+		elapsed := epoch - bondingHeights[validator]/params.EpochLength
+		if err := k.BondingHeights.Set(ctx, sdk.ConsAddress(validator), -elapsed*params.EpochLength); err != nil {
+			return err
+		}
+	}
+
+	deviations := make(map[string]int64)
+	err = k.Deviations.Walk(ctx, collections.NewPrefixedPairRange[int64, sdk.ConsAddress](epoch), func(key collections.Pair[int64, sdk.ConsAddress], deviation int64) (bool, error) {
+		deviations[string(key.K2())] = deviation
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := k.Deviations.Clear(ctx, nil); err != nil {
+		return err
+	}
+	for _, validator := range sortedKeys(deviations) {
+		if err := k.Deviations.Set(ctx, collections.Join(int64(0), sdk.ConsAddress(validator)), deviations[validator]); err != nil {
+			return err
+		}
+	}
+
+	if err := k.Commitments.Clear(ctx, nil); err != nil {
+		return err
+	}
+	if err := k.Explanations.Clear(ctx, nil); err != nil {
+		return err
+	}
+	return k.SealedBids.Clear(ctx, nil)
+}
@@ -0,0 +1,96 @@
+package weightskeeper_test
+
+import (
+	"testing"
+
+	"cosmossdk.io/collections"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenesisRoundTrip(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+	val1 := sdk.ConsAddress("val1________________").String()
+	gs := weightskeeper.GenesisState{
+		Params:         weightskeeper.DefaultParams(),
+		Weights:        []weightskeeper.GenesisWeight{{Validator: val1, Record: weightskeeper.WeightRecord{Weight: 12, Epoch: 3, Height: 310}}},
+		BondingHeights: []weightskeeper.ValidatorValue{{Validator: val1, Value: 40}},
+		Deviations:     []weightskeeper.GenesisDeviation{{Epoch: 3, Validator: val1, Deviation: 7}},
+		OutlierStreaks: []weightskeeper.ValidatorValue{{Validator: val1, Value: 2}},
+		Penalties:      []weightskeeper.ValidatorValue{{Validator: val1, Value: 5}},
+	}
+	require.NoError(t, keeper.InitGenesis(ctx, gs))
+
+	exported, err := keeper.ExportGenesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, gs, *exported)
+
+	// duplicate and out of range entries are rejected
+	invalid := gs
+	invalid.Penalties = append(invalid.Penalties, invalid.Penalties[0])
+	require.Error(t, invalid.Validate())
+	invalid = gs
+	invalid.Weights = []weightskeeper.GenesisWeight{{Validator: val1, Record: weightskeeper.WeightRecord{Weight: weightskeeper.MaxWeight + 1}}}
+	require.Error(t, invalid.Validate())
+	invalid = gs
+	invalid.Params.BaseWeight = weightskeeper.MaxWeight + 1
+	require.Error(t, invalid.Validate())
+
+	// validators are identified by their consensus address
+	invalid = gs
+	invalid.Penalties = []weightskeeper.ValidatorValue{{Validator: sdk.ValAddress("val1________________").String(), Value: 5}}
+	require.Error(t, invalid.Validate())
+	invalid = gs
+	invalid.Deviations = []weightskeeper.GenesisDeviation{{Epoch: 3, Validator: "val1", Deviation: 7}}
+	require.Error(t, invalid.Validate())
+}
+
+func TestPrepForZeroHeightGenesis(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+	params := weightskeeper.DefaultParams()
+	params.EpochLength = 10
+	params.GracePeriodEpochs = 5
+	keeper.SetParams(ctx, params)
+
+	// exported at height 123, in epoch 12
+	val1 := sdk.ConsAddress("val1________________")
+	ctx = ctx.WithBlockHeight(123)
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(val1): 12}))
+	require.NoError(t, keeper.BondingHeights.Set(ctx, val1, 95))
+	require.NoError(t, keeper.Deviations.Set(ctx, collections.Join(int64(11), val1), 9))
+	require.NoError(t, keeper.Deviations.Set(ctx, collections.Join(int64(12), val1), 4))
+	require.NoError(t, keeper.OutlierStreaks.Set(ctx, val1, 2))
+	require.NoError(t, keeper.Penalties.Set(ctx, val1, 5))
+	require.NoError(t, keeper.SetCommitments(ctx, map[string][]byte{string(val1): {1}}))
+	require.NoError(t, keeper.AddSealedBid(ctx, []byte{2}, "bidder", 120))
+
+	weights := map[string]int64{string(val1): 12, string(sdk.ConsAddress("val2________________")): 30}
+	graced, err := keeper.ApplyGracePeriod(ctx, weights)
+	require.NoError(t, err)
+
+	require.NoError(t, keeper.PrepForZeroHeightGenesis(ctx))
+	gs, err := keeper.ExportGenesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.GenesisState{
+		Params:  params,
+		Weights: []weightskeeper.GenesisWeight{{Validator: val1.String(), Record: weightskeeper.WeightRecord{Weight: 12}}},
+		// three epochs elapsed since bonding, as at the export
+		BondingHeights: []weightskeeper.ValidatorValue{{Validator: val1.String(), Value: -30}},
+		Deviations:     []weightskeeper.GenesisDeviation{{Epoch: 0, Validator: val1.String(), Deviation: 4}},
+		OutlierStreaks: []weightskeeper.ValidatorValue{{Validator: val1.String(), Value: 2}},
+		Penalties:      []weightskeeper.ValidatorValue{{Validator: val1.String(), Value: 5}},
+	}, *gs)
+
+	commitment, err := keeper.GetCommitment(ctx, val1)
+	require.NoError(t, err)
+	require.Empty(t, commitment)
+	has, err := keeper.HasSealedBid(ctx, []byte{2}, "bidder")
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// the grace period continues where it stood on the restarted chain
+	restarted, err := keeper.ApplyGracePeriod(ctx.WithBlockHeight(1), weights)
+	require.NoError(t, err)
+	require.Equal(t, graced, restarted)
+}
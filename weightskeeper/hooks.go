@@ -0,0 +1,86 @@
+package weightskeeper
+
+import (
+	"context"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+var _ stakingtypes.StakingHooks = WeightsKeeper{}
+
+// AfterValidatorCreated starts a new validator off at the base weight. The hook only gets the
+// operator address, the consensus address is looked up from the validator staking just stored.
+func (k WeightsKeeper) AfterValidatorCreated(ctx context.Context, valAddr sdk.ValAddress) error {
+	validator, err := k.stakingKeeper.GetValidator(ctx, valAddr)
+	if err != nil {
+		return err
+	}
+	consAddr, err := validator.GetConsAddr()
+	if err != nil {
+		return err
+	}
+	return k.setWeight(ctx, consAddr, k.GetParams(ctx).BaseWeight)
+}
+
+// AfterValidatorRemoved drops the weight of a validator that no longer exists
+func (k WeightsKeeper) AfterValidatorRemoved(ctx context.Context, consAddr sdk.ConsAddress, _ sdk.ValAddress) error {
+	if err := k.BondingHeights.Remove(ctx, consAddr); err != nil {
+		return err
+	}
+	if err := k.OutlierStreaks.Remove(ctx, consAddr); err != nil {
+		return err
+	}
+	if err := k.Penalties.Remove(ctx, consAddr); err != nil {
+		return err
+	}
+	if err := k.Explanations.Remove(ctx, consAddr); err != nil {
+		return err
+	}
+	return k.Weights.Remove(ctx, consAddr)
+}
+
+// AfterValidatorBeginUnbonding resets the weight of a validator leaving the active set, so it
+// does not carry a bonus it earned earlier if it bonds again
+func (k WeightsKeeper) AfterValidatorBeginUnbonding(ctx context.Context, consAddr sdk.ConsAddress, _ sdk.ValAddress) error {
+	return k.setWeight(ctx, consAddr, k.GetParams(ctx).BaseWeight)
+}
+
+func (k WeightsKeeper) BeforeValidatorModified(_ context.Context, _ sdk.ValAddress) error {
+	return nil
+}
+
+// AfterValidatorBonded records the height a validator first bonded at, which starts its grace
+// period. The heights recorded for the validators bonded in genesis are dropped by InitGenesis.
+func (k WeightsKeeper) AfterValidatorBonded(ctx context.Context, consAddr sdk.ConsAddress, _ sdk.ValAddress) error {
+	has, err := k.BondingHeights.Has(ctx, consAddr)
+	if err != nil || has {
+		return err
+	}
+	return k.BondingHeights.Set(ctx, consAddr, sdk.UnwrapSDKContext(ctx).BlockHeight())
+}
+
+func (k WeightsKeeper) BeforeDelegationCreated(_ context.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
+	return nil
+}
+
+func (k WeightsKeeper) BeforeDelegationSharesModified(_ context.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
+	return nil
+}
+
+func (k WeightsKeeper) BeforeDelegationRemoved(_ context.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
+	return nil
+}
+
+func (k WeightsKeeper) AfterDelegationModified(_ context.Context, _ sdk.AccAddress, _ sdk.ValAddress) error {
+	return nil
+}
+
+func (k WeightsKeeper) BeforeValidatorSlashed(_ context.Context, _ sdk.ValAddress, _ math.LegacyDec) error {
+	return nil
+}
+
+func (k WeightsKeeper) AfterUnbondingInitiated(_ context.Context, _ uint64) error {
+	return nil
+}
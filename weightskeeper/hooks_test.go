@@ -0,0 +1,40 @@
+package weightskeeper_test
+
+import (
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStakingHooks(t *testing.T) {
+	validator := newValidator(sdk.ValAddress("validator___________"), 100_000_000)
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{validators: []stakingtypes.Validator{validator}})
+	params := weightskeeper.DefaultParams()
+	params.BaseWeight = 10
+	keeper.SetParams(ctx, params)
+
+	valAddr := sdk.ValAddress("validator___________")
+	valConsAddr := consAddr(validator)
+
+	// a new validator starts at the base weight, kept under its consensus address
+	require.NoError(t, keeper.AfterValidatorCreated(ctx, valAddr))
+	weight, err := keeper.GetWeight(ctx, valConsAddr)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), weight)
+
+	// unbonding drops any earned bonus back to the base weight
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(valConsAddr): 40}))
+	require.NoError(t, keeper.AfterValidatorBeginUnbonding(ctx, valConsAddr, valAddr))
+	weight, err = keeper.GetWeight(ctx, valConsAddr)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), weight)
+
+	// removal deletes the validator's state
+	require.NoError(t, keeper.AfterValidatorRemoved(ctx, valConsAddr, valAddr))
+	has, err := keeper.Weights.Has(ctx, valConsAddr)
+	require.NoError(t, err)
+	require.False(t, has)
+}
@@ -0,0 +1,133 @@
+package weightskeeper
+
+import (
+	"fmt"
+
+	"cosmossdk.io/math"
+)
+
+// ws metrics, the validator performance data weights are derived from
+const (
+	// MetricUptime is the percentage of the latest blocks a validator signed
+	MetricUptime = "uptime"
+	// MetricGovernance is the percentage of the governance proposals a validator voted on
+	MetricGovernance = "governance"
+	// MetricContributions is the number of contributions a validator made to the main repo
+	MetricContributions = "contributions"
+)
+
+// metricNames lists the known metrics
+var metricNames = []string{MetricUptime, MetricGovernance, MetricContributions}
+
+// MetricCoefficient is how much a metric counts towards the weight of a validator
+type MetricCoefficient struct {
+	Metric      string         `json:"metric"`
+	Coefficient math.LegacyDec `json:"coefficient"`
+}
+
+// DefaultMetricCoefficients returns the default coefficients of the metrics weights are
+// derived from, which add up to one
+func DefaultMetricCoefficients() []MetricCoefficient {
+	return []MetricCoefficient{
+		{Metric: MetricUptime, Coefficient: math.LegacyNewDecWithPrec(4, 1)},
+		{Metric: MetricGovernance, Coefficient: math.LegacyNewDecWithPrec(4, 1)},
+		{Metric: MetricContributions, Coefficient: math.LegacyNewDecWithPrec(2, 1)},
+	}
+}
+
+// ValidateMetricCoefficients checks that the coefficients are given for known metrics, once
+// each, are not negative and add up to one, so that weights stay within [0, MaxWeight]
+func ValidateMetricCoefficients(coefficients []MetricCoefficient) error {
+	total := math.LegacyZeroDec()
+	seen := make(map[string]bool, len(coefficients))
+	for _, mc := range coefficients {
+		if !isMetric(mc.Metric) {
+			return fmt.Errorf("unknown metric %q", mc.Metric)
+		}
+		if seen[mc.Metric] {
+			return fmt.Errorf("duplicate metric %q", mc.Metric)
+		}
+		seen[mc.Metric] = true
+		if mc.Coefficient.IsNil() || mc.Coefficient.IsNegative() {
+			return fmt.Errorf("invalid coefficient %s of %s", mc.Coefficient, mc.Metric)
+		}
+		total = total.Add(mc.Coefficient)
+	}
+	if !total.Equal(math.LegacyOneDec()) {
+		return fmt.Errorf("coefficients add up to %s, not one", total)
+	}
+	return nil
+}
+
+// ValidateMetrics checks that only known metrics are given and that no raw value is negative
+func ValidateMetrics(metrics map[string]ValidatorMap) error {
+	for metric, values := range metrics {
+		if !isMetric(metric) {
+			return fmt.Errorf("unknown metric %q", metric)
+		}
+		for validator, value := range values {
+			if value < 0 {
+				return fmt.Errorf("negative %s of %X", metric, validator)
+			}
+		}
+	}
+	return nil
+}
+
+func isMetric(metric string) bool {
+	for _, name := range metricNames {
+		if name == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreMetrics derives the weight of every validator from the raw metrics, keyed by metric
+// then validator, returning the weights along with the score of each metric. Each metric is
+// normalized against its highest value in the set and weighted by its coefficient, which
+// scales the weights to [0, MaxWeight]. A validator missing from a metric scores zero on it,
+// and metrics without a coefficient are not scored.
+func ScoreMetrics(metrics map[string]ValidatorMap, coefficients []MetricCoefficient) (map[string]int64, map[string][]MetricScore) {
+	validators := make(map[string]bool)
+	for _, values := range metrics {
+		for validator := range values {
+			validators[validator] = true
+		}
+	}
+
+	weights := make(map[string]int64, len(validators))
+	scores := make(map[string][]MetricScore, len(validators))
+	for _, mc := range coefficients {
+		var highest int64
+		for _, value := range metrics[mc.Metric] {
+			if value > highest {
+				highest = value
+			}
+		}
+
+		for validator := range validators {
+			raw := metrics[mc.Metric][validator]
+			normalized := math.LegacyZeroDec()
+			if highest > 0 && raw > 0 {
+				normalized = math.LegacyNewDec(raw).QuoInt64(highest)
+			}
+			scores[validator] = append(scores[validator], MetricScore{
+				Metric:       mc.Metric,
+				Raw:          raw,
+				Normalized:   normalized,
+				Coefficient:  mc.Coefficient,
+				Contribution: normalized.Mul(mc.Coefficient).MulInt64(MaxWeight),
+			})
+		}
+	}
+
+	for validator, validatorScores := range scores {
+		total := math.LegacyZeroDec()
+		for _, score := range validatorScores {
+			total = total.Add(score.Contribution)
+		}
+		weights[validator] = total.TruncateInt64()
+	}
+	return weights, scores
+}
@@ -0,0 +1,179 @@
+package weightskeeper
+
+import (
+	"errors"
+
+	"cosmossdk.io/collections"
+	collcodec "cosmossdk.io/collections/codec"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// ConsensusVersion is the version of the ws store layout, bumped by every store migration
+const ConsensusVersion = 3
+
+// Migrator migrates the ws store between consensus versions
+type Migrator struct {
+	keeper WeightsKeeper
+}
+
+func NewMigrator(keeper WeightsKeeper) Migrator {
+	return Migrator{keeper: keeper}
+}
+
+// legacyMap returns a map of the layout before version 3, which keyed validators by operator
+// address
+func legacyMap[V any](m Migrator, prefix collections.Prefix, name string, value collcodec.ValueCodec[V]) collections.Map[string, V] {
+	return collections.NewMap(collections.NewSchemaBuilder(m.keeper.storeService), prefix, name, collections.StringKey, value)
+}
+
+// Migrate1to2 converts the weights stored as plain integers into weight records. The legacy
+// weights do not tell when they were set, so they are recorded as set at the upgrade height.
+func (m Migrator) Migrate1to2(ctx sdk.Context) error {
+	legacyWeights := legacyMap(m, weight_shift.WeightsKey, "weights", collections.Int64Value)
+
+	weights := make(map[string]int64)
+	err := legacyWeights.Walk(ctx, nil, func(validator string, weight int64) (bool, error) {
+		weights[validator] = weight
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	records := legacyMap(m, weight_shift.WeightsKey, "weights", WeightRecordValue)
+	for _, validator := range sortedKeys(weights) {
+		record := WeightRecord{Weight: weights[validator], Epoch: m.keeper.CurrentEpoch(ctx), Height: ctx.BlockHeight()}
+		if err := records.Set(ctx, validator, record); err != nil {
+			return err
+		}
+	}
+	ctx.Logger().Info("migrated ws weights to weight records", "validators", len(weights))
+	return nil
+}
+
+// Migrate2to3 re-keys the validator state by consensus address instead of operator address,
+// which is how votes and vote extensions identify validators. Entries of validators staking
+// does not know anymore are dropped. The explanations of the weights are dropped too, the next
+// aggregation records them again.
+func (m Migrator) Migrate2to3(ctx sdk.Context) error {
+	consAddrs := make(map[string]sdk.ConsAddress)
+	dropped := make(map[string]bool)
+	consAddr := func(validator string) (sdk.ConsAddress, error) {
+		if addr, ok := consAddrs[validator]; ok || dropped[validator] {
+			return addr, nil
+		}
+		valAddr, err := sdk.ValAddressFromBech32(validator)
+		if err != nil {
+			dropped[validator] = true
+			return nil, nil
+		}
+		val, err := m.keeper.stakingKeeper.GetValidator(ctx, valAddr)
+		if errors.Is(err, stakingtypes.ErrNoValidatorFound) {
+			dropped[validator] = true
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		addr, err := val.GetConsAddr()
+		if err != nil {
+			return nil, err
+		}
+		consAddrs[validator] = addr
+		return addr, nil
+	}
+
+	weights := make(map[string]WeightRecord)
+	err := legacyMap(m, weight_shift.WeightsKey, "weights", WeightRecordValue).Walk(ctx, nil, func(validator string, record WeightRecord) (bool, error) {
+		weights[validator] = record
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := m.keeper.Weights.Clear(ctx, nil); err != nil {
+		return err
+	}
+	for _, validator := range sortedKeys(weights) {
+		addr, err := consAddr(validator)
+		if err != nil {
+			return err
+		}
+		if addr == nil {
+			continue
+		}
+		if err := m.keeper.Weights.Set(ctx, addr, weights[validator]); err != nil {
+			return err
+		}
+	}
+
+	for _, values := range []struct {
+		prefix collections.Prefix
+		name   string
+		m      collections.Map[sdk.ConsAddress, int64]
+	}{
+		{weight_shift.BondingHeightsKey, "bonding_heights", m.keeper.BondingHeights},
+		{weight_shift.OutlierStreaksKey, "outlier_streaks", m.keeper.OutlierStreaks},
+		{weight_shift.PenaltiesKey, "penalties", m.keeper.Penalties},
+	} {
+		legacy := make(map[string]int64)
+		err := legacyMap(m, values.prefix, values.name, collections.Int64Value).Walk(ctx, nil, func(validator string, value int64) (bool, error) {
+			legacy[validator] = value
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := values.m.Clear(ctx, nil); err != nil {
+			return err
+		}
+		for _, validator := range sortedKeys(legacy) {
+			addr, err := consAddr(validator)
+			if err != nil {
+				return err
+			}
+			if addr == nil {
+				continue
+			}
+			if err := values.m.Set(ctx, addr, legacy[validator]); err != nil {
+				return err
+			}
+		}
+	}
+
+	legacyDeviations := collections.NewMap(collections.NewSchemaBuilder(m.keeper.storeService), weight_shift.DeviationsKey,
+		"deviations", collections.PairKeyCodec(collections.Int64Key, collections.StringKey), collections.Int64Value)
+	var deviationKeys []collections.Pair[int64, string]
+	var deviations []int64
+	err = legacyDeviations.Walk(ctx, nil, func(key collections.Pair[int64, string], deviation int64) (bool, error) {
+		deviationKeys = append(deviationKeys, key)
+		deviations = append(deviations, deviation)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := m.keeper.Deviations.Clear(ctx, nil); err != nil {
+		return err
+	}
+	for i, key := range deviationKeys {
+		addr, err := consAddr(key.K2())
+		if err != nil {
+			return err
+		}
+		if addr == nil {
+			continue
+		}
+		if err := m.keeper.Deviations.Set(ctx, collections.Join(key.K1(), addr), deviations[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := m.keeper.Explanations.Clear(ctx, nil); err != nil {
+		return err
+	}
+	ctx.Logger().Info("migrated ws state to consensus addresses", "validators", len(consAddrs), "dropped", len(dropped))
+	return nil
+}
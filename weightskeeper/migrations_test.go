@@ -0,0 +1,77 @@
+package weightskeeper_test
+
+import (
+	"testing"
+
+	"cosmossdk.io/collections"
+	storetypes "cosmossdk.io/store/types"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate1to2(t *testing.T) {
+	wsKey := storetypes.NewKVStoreKey(weight_shift.StoreKey)
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{}, wsKey)
+	ctx = ctx.WithBlockHeight(250)
+
+	// the first layout stored the weights as plain integers
+	legacyWeights := collections.NewMap(collections.NewSchemaBuilder(runtime.NewKVStoreService(wsKey)),
+		weight_shift.WeightsKey, "weights", collections.StringKey, collections.Int64Value)
+	require.NoError(t, legacyWeights.Set(ctx, "val1", 12))
+	require.NoError(t, legacyWeights.Set(ctx, "val2", 0))
+
+	require.NoError(t, weightskeeper.NewMigrator(keeper).Migrate1to2(ctx))
+
+	// the second layout still keyed them by operator address
+	records := collections.NewMap(collections.NewSchemaBuilder(runtime.NewKVStoreService(wsKey)),
+		weight_shift.WeightsKey, "weights", collections.StringKey, weightskeeper.WeightRecordValue)
+	record, err := records.Get(ctx, "val1")
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.WeightRecord{Weight: 12, Epoch: 2, Height: 250}, record)
+	record, err = records.Get(ctx, "val2")
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.WeightRecord{Epoch: 2, Height: 250}, record)
+}
+
+func TestMigrate2to3(t *testing.T) {
+	wsKey := storetypes.NewKVStoreKey(weight_shift.StoreKey)
+	validator := newValidator(sdk.ValAddress("validator___________"), 100_000_000)
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{validators: []stakingtypes.Validator{validator}}, wsKey)
+	storeService := runtime.NewKVStoreService(wsKey)
+
+	// the second layout keyed the validators by operator address
+	removed := sdk.ValAddress("removed_____________").String()
+	legacyWeights := collections.NewMap(collections.NewSchemaBuilder(storeService),
+		weight_shift.WeightsKey, "weights", collections.StringKey, weightskeeper.WeightRecordValue)
+	require.NoError(t, legacyWeights.Set(ctx, validator.OperatorAddress, weightskeeper.WeightRecord{Weight: 12, Epoch: 3, Height: 30}))
+	require.NoError(t, legacyWeights.Set(ctx, removed, weightskeeper.WeightRecord{Weight: 20}))
+	require.NoError(t, legacyWeights.Set(ctx, "val1", weightskeeper.WeightRecord{Weight: 30}))
+	legacyPenalties := collections.NewMap(collections.NewSchemaBuilder(storeService),
+		weight_shift.PenaltiesKey, "penalties", collections.StringKey, collections.Int64Value)
+	require.NoError(t, legacyPenalties.Set(ctx, validator.OperatorAddress, 5))
+	legacyDeviations := collections.NewMap(collections.NewSchemaBuilder(storeService), weight_shift.DeviationsKey,
+		"deviations", collections.PairKeyCodec(collections.Int64Key, collections.StringKey), collections.Int64Value)
+	require.NoError(t, legacyDeviations.Set(ctx, collections.Join(int64(3), validator.OperatorAddress), 7))
+	require.NoError(t, legacyDeviations.Set(ctx, collections.Join(int64(3), removed), 9))
+
+	require.NoError(t, weightskeeper.NewMigrator(keeper).Migrate2to3(ctx))
+
+	// the state of the validator is kept under its consensus address, the rest is dropped
+	record, err := keeper.Weights.Get(ctx, consAddr(validator))
+	require.NoError(t, err)
+	require.Equal(t, weightskeeper.WeightRecord{Weight: 12, Epoch: 3, Height: 30}, record)
+	weights, err := keeper.GetWeights(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{string(consAddr(validator)): 12}, weights)
+	penalty, err := keeper.Penalties.Get(ctx, consAddr(validator))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), penalty)
+
+	gs, err := keeper.ExportGenesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []weightskeeper.GenesisDeviation{{Epoch: 3, Validator: consAddr(validator).String(), Deviation: 7}}, gs.Deviations)
+}
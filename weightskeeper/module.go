@@ -0,0 +1,119 @@
+package weightskeeper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	autocliv1 "cosmossdk.io/api/cosmos/autocli/v1"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+var (
+	_ module.AppModuleBasic      = AppModule{}
+	_ module.HasServices         = AppModule{}
+	_ module.HasConsensusVersion = AppModule{}
+	_ module.HasGenesis          = AppModule{}
+)
+
+// AppModule registers the ws module with the module manager, which runs its genesis, tracks
+// its consensus version and runs its migrations on upgrades. The ws blocks and vote extensions
+// are handled by the app itself.
+type AppModule struct {
+	keeper WeightsKeeper
+}
+
+func NewAppModule(keeper WeightsKeeper) AppModule {
+	return AppModule{keeper: keeper}
+}
+
+func (AppModule) IsOnePerModuleType() {}
+
+func (AppModule) IsAppModule() {}
+
+func (AppModule) Name() string {
+	return weight_shift.ModuleName
+}
+
+func (AppModule) RegisterLegacyAminoCodec(*codec.LegacyAmino) {}
+
+func (AppModule) RegisterInterfaces(codectypes.InterfaceRegistry) {}
+
+// RegisterGRPCGatewayRoutes registers the REST routes of the ws query service
+func (AppModule) RegisterGRPCGatewayRoutes(clientCtx client.Context, mux *runtime.ServeMux) {
+	if err := RegisterQueryHandlerClient(context.Background(), mux, NewQueryClient(clientCtx)); err != nil {
+		panic(fmt.Sprintf("failed to register the %s gateway routes: %v", weight_shift.ModuleName, err))
+	}
+}
+
+// DefaultGenesis returns the default ws genesis state
+func (AppModule) DefaultGenesis(codec.JSONCodec) json.RawMessage {
+	bz, err := json.Marshal(DefaultGenesisState())
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+// ValidateGenesis decodes and validates the ws genesis state
+func (AppModule) ValidateGenesis(_ codec.JSONCodec, _ client.TxEncodingConfig, bz json.RawMessage) error {
+	var gs GenesisState
+	if err := json.Unmarshal(bz, &gs); err != nil {
+		return fmt.Errorf("failed to decode %s genesis: %w", weight_shift.ModuleName, err)
+	}
+	return gs.Validate()
+}
+
+// InitGenesis imports the ws genesis state. It runs after staking, so the validators bonded in
+// genesis are already known.
+func (am AppModule) InitGenesis(ctx sdk.Context, _ codec.JSONCodec, bz json.RawMessage) {
+	var gs GenesisState
+	if err := json.Unmarshal(bz, &gs); err != nil {
+		panic(fmt.Sprintf("failed to decode %s genesis: %v", weight_shift.ModuleName, err))
+	}
+	if err := am.keeper.InitGenesis(ctx, gs); err != nil {
+		panic(fmt.Sprintf("failed to init %s genesis: %v", weight_shift.ModuleName, err))
+	}
+}
+
+// ExportGenesis exports the ws genesis state
+func (am AppModule) ExportGenesis(ctx sdk.Context, _ codec.JSONCodec) json.RawMessage {
+	gs, err := am.keeper.ExportGenesis(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("failed to export %s genesis: %v", weight_shift.ModuleName, err))
+	}
+	bz, err := json.Marshal(gs)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (AppModule) ConsensusVersion() uint64 {
+	return ConsensusVersion
+}
+
+// AutoCLIOptions keeps autocli from generating commands for the ws query service; its commands
+// are written by hand so that explanations print as a table
+func (AppModule) AutoCLIOptions() *autocliv1.ModuleOptions {
+	return &autocliv1.ModuleOptions{}
+}
+
+// RegisterServices registers the ws query service and the ws store migrations
+func (am AppModule) RegisterServices(cfg module.Configurator) {
+	RegisterQueryServer(cfg.QueryServer(), NewQueryServer(am.keeper))
+
+	m := NewMigrator(am.keeper)
+	if err := cfg.RegisterMigration(weight_shift.ModuleName, 1, m.Migrate1to2); err != nil {
+		panic(fmt.Sprintf("failed to migrate %s from version 1 to 2: %v", weight_shift.ModuleName, err))
+	}
+	if err := cfg.RegisterMigration(weight_shift.ModuleName, 2, m.Migrate2to3); err != nil {
+		panic(fmt.Sprintf("failed to migrate %s from version 2 to 3: %v", weight_shift.ModuleName, err))
+	}
+}
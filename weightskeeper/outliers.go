@@ -0,0 +1,221 @@
+package weightskeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// Report holds the weights a single validator reported through its vote extension, keyed by
+// consensus address
+type Report struct {
+	ConsAddr sdk.ConsAddress
+	Weights  map[string]int64
+}
+
+// RecordDeviations records for the current epoch how far the weights each validator reported
+// deviated from the aggregated median. Only the largest deviation of an epoch is kept.
+func (k WeightsKeeper) RecordDeviations(ctx context.Context, aggregated map[string]int64, reports []Report) error {
+	epoch := k.CurrentEpoch(ctx)
+	for _, report := range reports {
+		_, err := k.stakingKeeper.GetValidatorByConsAddr(ctx, report.ConsAddr)
+		if errors.Is(err, stakingtypes.ErrNoValidatorFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		key := collections.Join(epoch, report.ConsAddr)
+		deviation := reportDeviation(aggregated, report.Weights)
+		previous, err := k.Deviations.Get(ctx, key)
+		if err == nil && previous >= deviation {
+			continue
+		}
+		if err != nil && !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		if err := k.Deviations.Set(ctx, key, deviation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportDeviation returns the largest absolute difference between a reported weight and the
+// aggregated median; a validator missing from the report counts as reported at zero.
+func reportDeviation(aggregated, reported map[string]int64) int64 {
+	var deviation int64
+	for validator, median := range aggregated {
+		diff := reported[validator] - median
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > deviation {
+			deviation = diff
+		}
+	}
+	return deviation
+}
+
+// ProcessOutliers closes the previous epoch on the first block of every epoch. Validators whose
+// deviation exceeded OutlierThreshold extend their outlier streak, all others have it reset, and
+// a validator reaching OutlierEpochs consecutive outlier epochs is penalized. Penalized
+// validators that reported within the threshold recover PenaltyRecovery of their penalty.
+func (k WeightsKeeper) ProcessOutliers(ctx context.Context) error {
+	params := k.GetParams(ctx)
+	height := sdk.UnwrapSDKContext(ctx).BlockHeight()
+	if height == 0 || height%params.EpochLength != 0 {
+		return nil
+	}
+	epoch := k.CurrentEpoch(ctx) - 1
+
+	var outliers, compliant []sdk.ConsAddress
+	isOutlier := make(map[string]bool)
+	var recorded []collections.Pair[int64, sdk.ConsAddress]
+	rng := collections.NewPrefixedPairRange[int64, sdk.ConsAddress](epoch)
+	err := k.Deviations.Walk(ctx, rng, func(key collections.Pair[int64, sdk.ConsAddress], deviation int64) (bool, error) {
+		recorded = append(recorded, key)
+		if deviation > params.OutlierThreshold {
+			outliers = append(outliers, key.K2())
+			isOutlier[string(key.K2())] = true
+		} else {
+			compliant = append(compliant, key.K2())
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, validator := range compliant {
+		if err := k.recoverPenalty(ctx, params, validator); err != nil {
+			return err
+		}
+	}
+
+	var reset []sdk.ConsAddress
+	err = k.OutlierStreaks.Walk(ctx, nil, func(validator sdk.ConsAddress, _ int64) (bool, error) {
+		if !isOutlier[string(validator)] {
+			reset = append(reset, validator)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, validator := range reset {
+		if err := k.OutlierStreaks.Remove(ctx, validator); err != nil {
+			return err
+		}
+	}
+
+	for _, validator := range outliers {
+		streak, err := k.OutlierStreaks.Get(ctx, validator)
+		if err != nil && !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		streak++
+		if streak >= params.OutlierEpochs {
+			if err := k.penalize(ctx, params, validator, epoch, streak); err != nil {
+				return err
+			}
+			streak = 0
+		}
+		if err := k.OutlierStreaks.Set(ctx, validator, streak); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range recorded {
+		if err := k.Deviations.Remove(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// penalize deducts the outlier penalty from the validator's weight and, if enabled, jails it
+func (k WeightsKeeper) penalize(ctx context.Context, params Params, validator sdk.ConsAddress, epoch, streak int64) error {
+	penalty, err := k.Penalties.Get(ctx, validator)
+	if err != nil && !errors.Is(err, collections.ErrNotFound) {
+		return err
+	}
+	if err := k.Penalties.Set(ctx, validator, penalty+params.OutlierPenalty); err != nil {
+		return err
+	}
+
+	jailed := false
+	if params.JailOutliers && k.slashingKeeper != nil {
+		jailed, err = k.jail(ctx, params, validator)
+		if err != nil {
+			return err
+		}
+	}
+
+	sdk.UnwrapSDKContext(ctx).EventManager().EmitEvent(
+		sdk.NewEvent(
+			EventTypeOutlierPenalty,
+			sdk.NewAttribute(AttributeKeyValidator, validator.String()),
+			sdk.NewAttribute(AttributeKeyEpoch, fmt.Sprint(epoch)),
+			sdk.NewAttribute(AttributeKeyOutlierEpochs, fmt.Sprint(streak)),
+			sdk.NewAttribute(AttributeKeyPenalty, fmt.Sprint(params.OutlierPenalty)),
+			sdk.NewAttribute(AttributeKeyJailed, fmt.Sprint(jailed)),
+		),
+	)
+	return nil
+}
+
+// recoverPenalty gives PenaltyRecovery of its penalty back to a validator that closed an epoch
+// without being an outlier, dropping the penalty once it is fully recovered
+func (k WeightsKeeper) recoverPenalty(ctx context.Context, params Params, validator sdk.ConsAddress) error {
+	penalty, err := k.Penalties.Get(ctx, validator)
+	if errors.Is(err, collections.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	penalty -= params.PenaltyRecovery
+	if penalty <= 0 {
+		return k.Penalties.Remove(ctx, validator)
+	}
+	return k.Penalties.Set(ctx, validator, penalty)
+}
+
+// jail jails the validator and keeps it from unjailing for OutlierJailDuration
+func (k WeightsKeeper) jail(ctx context.Context, params Params, consAddr sdk.ConsAddress) (bool, error) {
+	val, err := k.stakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	if err != nil {
+		return false, err
+	}
+	if val.IsJailed() {
+		return false, nil
+	}
+	if err := k.slashingKeeper.Jail(ctx, consAddr); err != nil {
+		return false, err
+	}
+	jailedUntil := sdk.UnwrapSDKContext(ctx).BlockTime().Add(params.OutlierJailDuration)
+	return true, k.slashingKeeper.JailUntil(ctx, consAddr, jailedUntil)
+}
+
+// ApplyPenalties deducts the accumulated outlier penalties from the given weights
+func (k WeightsKeeper) ApplyPenalties(ctx context.Context, weights map[string]int64) (map[string]int64, error) {
+	result := make(map[string]int64, len(weights))
+	for validator, weight := range weights {
+		penalty, err := k.Penalties.Get(ctx, sdk.ConsAddress(validator))
+		if err != nil && !errors.Is(err, collections.ErrNotFound) {
+			return nil, err
+		}
+		weight -= penalty
+		if weight < 0 {
+			weight = 0
+		}
+		result[validator] = weight
+	}
+	return result, nil
+}
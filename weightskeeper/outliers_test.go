@@ -0,0 +1,132 @@
+package weightskeeper_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cosmossdk.io/collections"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlierPenalty(t *testing.T) {
+	honest := newValidator(sdk.ValAddress("honest______________"), 100)
+	liar := newValidator(sdk.ValAddress("liar________________"), 100)
+	sk := &mockStakingKeeper{validators: []stakingtypes.Validator{honest, liar}}
+	ctx, keeper := setupKeeper(t, sk)
+
+	params := weightskeeper.DefaultParams()
+	params.EpochLength = 10
+	params.OutlierThreshold = 5
+	params.OutlierEpochs = 2
+	params.OutlierPenalty = 7
+	params.PenaltyRecovery = 3
+	params.JailOutliers = true
+	params.OutlierJailDuration = time.Hour
+	keeper.SetParams(ctx, params)
+
+	aggregated := map[string]int64{string(consAddr(honest)): 20, string(consAddr(liar)): 20}
+	reports := []weightskeeper.Report{
+		{ConsAddr: consAddr(honest), Weights: map[string]int64{string(consAddr(honest)): 22, string(consAddr(liar)): 18}},
+		{ConsAddr: consAddr(liar), Weights: map[string]int64{string(consAddr(honest)): 0, string(consAddr(liar)): 55}},
+	}
+
+	// report through two epochs, the first one closed on the first block of the second
+	for _, height := range []int64{5, 10, 15} {
+		ctx = ctx.WithBlockHeight(height)
+		require.NoError(t, keeper.ProcessOutliers(ctx))
+		require.NoError(t, keeper.RecordDeviations(ctx, aggregated, reports))
+	}
+
+	// the first outlier epoch only starts a streak
+	streak, err := keeper.OutlierStreaks.Get(ctx, consAddr(liar))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), streak)
+	has, err := keeper.OutlierStreaks.Has(ctx, consAddr(honest))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// the second consecutive one is penalized
+	blockTime := time.Unix(1_700_000_000, 0).UTC()
+	ctx = ctx.WithBlockHeight(20).WithBlockTime(blockTime).WithEventManager(sdk.NewEventManager())
+	require.NoError(t, keeper.ProcessOutliers(ctx))
+
+	penalty, err := keeper.Penalties.Get(ctx, consAddr(liar))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), penalty)
+	require.Equal(t, []sdk.ConsAddress{consAddr(liar)}, sk.jailed)
+	// the jailed outlier cannot unjail before the jail duration has passed
+	require.Equal(t, blockTime.Add(time.Hour), sk.jailedUntil[string(consAddr(liar))])
+
+	events := ctx.EventManager().Events()
+	require.Len(t, events, 1)
+	require.Equal(t, weightskeeper.EventTypeOutlierPenalty, events[0].Type)
+
+	weights, err := keeper.ApplyPenalties(ctx, map[string]int64{string(consAddr(honest)): 20, string(consAddr(liar)): 5})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), weights[string(consAddr(honest))])
+	require.Equal(t, int64(0), weights[string(consAddr(liar))])
+
+	// the processed epochs leave no deviations behind
+	require.NoError(t, keeper.Deviations.Walk(ctx, nil, func(_ collections.Pair[int64, sdk.ConsAddress], _ int64) (bool, error) {
+		t.Fatal("unexpected deviation record")
+		return true, nil
+	}))
+}
+
+func TestOutlierPenaltyRecovery(t *testing.T) {
+	honest := newValidator(sdk.ValAddress("honest______________"), 100)
+	absent := newValidator(sdk.ValAddress("absent______________"), 100)
+	sk := &mockStakingKeeper{validators: []stakingtypes.Validator{honest, absent}}
+	ctx, keeper := setupKeeper(t, sk)
+
+	params := weightskeeper.DefaultParams()
+	params.EpochLength = 10
+	params.OutlierThreshold = 5
+	params.PenaltyRecovery = 3
+	keeper.SetParams(ctx, params)
+
+	require.NoError(t, keeper.Penalties.Set(ctx, consAddr(honest), 7))
+	require.NoError(t, keeper.Penalties.Set(ctx, consAddr(absent), 7))
+
+	aggregated := map[string]int64{string(consAddr(honest)): 20, string(consAddr(absent)): 20}
+	reports := []weightskeeper.Report{
+		{ConsAddr: consAddr(honest), Weights: map[string]int64{string(consAddr(honest)): 22, string(consAddr(absent)): 18}},
+	}
+	penalty := func(val stakingtypes.Validator) int64 {
+		penalty, err := keeper.Penalties.Get(ctx, consAddr(val))
+		if errors.Is(err, collections.ErrNotFound) {
+			return 0
+		}
+		require.NoError(t, err)
+		return penalty
+	}
+
+	// every epoch closed within the threshold gives back part of the penalty until it is gone
+	for i, expected := range []int64{4, 1, 0} {
+		ctx = ctx.WithBlockHeight(int64(i+1)*10 - 5)
+		require.NoError(t, keeper.RecordDeviations(ctx, aggregated, reports))
+		ctx = ctx.WithBlockHeight(int64(i+1) * 10)
+		require.NoError(t, keeper.ProcessOutliers(ctx))
+		require.Equal(t, expected, penalty(honest))
+	}
+	has, err := keeper.Penalties.Has(ctx, consAddr(honest))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// a validator that does not report keeps its penalty
+	require.Equal(t, int64(7), penalty(absent))
+
+	// an outlier epoch does not recover the penalty
+	require.NoError(t, keeper.Penalties.Set(ctx, consAddr(honest), 7))
+	ctx = ctx.WithBlockHeight(35)
+	require.NoError(t, keeper.RecordDeviations(ctx, aggregated, []weightskeeper.Report{
+		{ConsAddr: consAddr(honest), Weights: map[string]int64{string(consAddr(honest)): 55, string(consAddr(absent)): 0}},
+	}))
+	ctx = ctx.WithBlockHeight(40)
+	require.NoError(t, keeper.ProcessOutliers(ctx))
+	require.Equal(t, int64(7), penalty(honest))
+}
@@ -0,0 +1,197 @@
+package weightskeeper
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+)
+
+// Parameter store keys
+var (
+	KeyWeightedGovTally    = []byte("WeightedGovTally")
+	KeyBaseWeight          = []byte("BaseWeight")
+	KeyEpochLength         = []byte("EpochLength")
+	KeyGracePeriod         = []byte("GracePeriodEpochs")
+	KeyOutlierThreshold    = []byte("OutlierThreshold")
+	KeyOutlierEpochs       = []byte("OutlierEpochs")
+	KeyOutlierPenalty      = []byte("OutlierPenalty")
+	KeyPenaltyRecovery     = []byte("PenaltyRecovery")
+	KeyJailOutliers        = []byte("JailOutliers")
+	KeyOutlierJailDuration = []byte("OutlierJailDuration")
+	KeyCommitReveal        = []byte("CommitReveal")
+	KeyRejectFrontRuns     = []byte("RejectFrontRuns")
+	KeySealedBids          = []byte("SealedBids")
+	KeySealedBidTimeout    = []byte("SealedBidTimeout")
+	KeyEnableHeight        = []byte("EnableHeight")
+	KeyMetricCoefficients  = []byte("MetricCoefficients")
+)
+
+// Params defines the governance controlled parameters of the ws module. They are stored
+// in the module's x/params subspace so they can be changed through a ParamChangeProposal.
+type Params struct {
+	// WeightedGovTally multiplies each validator's governance voting power, and the power its
+	// delegators inherit from it, by the validator's weight multiplier
+	WeightedGovTally bool `json:"weighted_gov_tally"`
+	// BaseWeight is the weight a validator starts with when it joins the validator set
+	BaseWeight int64 `json:"base_weight"`
+	// EpochLength is the number of blocks in an epoch
+	EpochLength int64 `json:"epoch_length"`
+	// GracePeriodEpochs is the number of epochs after bonding during which a validator is
+	// given the median weight of the set instead of its metric based weight
+	GracePeriodEpochs int64 `json:"grace_period_epochs"`
+	// OutlierThreshold is how far a validator's reported weights may deviate from the
+	// aggregated median before the epoch counts as an outlier epoch for it
+	OutlierThreshold int64 `json:"outlier_threshold"`
+	// OutlierEpochs is the number of consecutive outlier epochs after which a validator is penalized
+	OutlierEpochs int64 `json:"outlier_epochs"`
+	// OutlierPenalty is the weight deducted from a validator each time it is penalized
+	OutlierPenalty int64 `json:"outlier_penalty"`
+	// PenaltyRecovery is the weight given back to a penalized validator for every epoch it
+	// closes without being an outlier, until its penalty is gone. Zero keeps penalties forever.
+	PenaltyRecovery int64 `json:"penalty_recovery"`
+	// JailOutliers also jails a penalized validator through the slashing keeper
+	JailOutliers bool `json:"jail_outliers"`
+	// OutlierJailDuration is how long a jailed outlier has to wait before it can unjail
+	OutlierJailDuration time.Duration `json:"outlier_jail_duration"`
+	// CommitReveal makes validators commit to the hash of their reported weights one height
+	// before revealing them, so they cannot copy the reports of others
+	CommitReveal bool `json:"commit_reveal"`
+	// RejectFrontRuns makes validators reject proposals in which the proposer front-runs a
+	// nameservice bid with its own, instead of only flagging them in the block events. Only
+	// bids placed from the account of the proposer's operator key are taken for its own.
+	RejectFrontRuns bool `json:"reject_front_runs"`
+	// SealedBids lets users commit to a nameservice bid in a tx memo and reveal it to the
+	// validators once the commitment is included, so the proposer cannot react to the bid
+	SealedBids bool `json:"sealed_bids"`
+	// SealedBidTimeout is the number of blocks after which an unrevealed sealed bid commitment
+	// is dropped
+	SealedBidTimeout int64 `json:"sealed_bid_timeout"`
+	// EnableHeight is the height from which validators report weights and the reported
+	// weights are applied. Weighting never starts before vote extensions are enabled in the
+	// consensus params, so the default of zero starts it along with them.
+	EnableHeight int64 `json:"enable_height"`
+	// MetricCoefficients is how much each metric counts towards the weight a validator
+	// reports for the others. The coefficients must add up to one.
+	MetricCoefficients []MetricCoefficient `json:"metric_coefficients"`
+}
+
+// ParamKeyTable returns the key table for the ws module params
+func ParamKeyTable() paramstypes.KeyTable {
+	return paramstypes.NewKeyTable().RegisterParamSet(&Params{})
+}
+
+// DefaultParams returns the default ws module params
+func DefaultParams() Params {
+	return Params{
+		WeightedGovTally:    false,
+		BaseWeight:          0,
+		EpochLength:         100,
+		GracePeriodEpochs:   10,
+		OutlierThreshold:    10,
+		OutlierEpochs:       3,
+		OutlierPenalty:      5,
+		PenaltyRecovery:     1,
+		JailOutliers:        false,
+		OutlierJailDuration: 10 * time.Minute,
+		CommitReveal:        false,
+		RejectFrontRuns:     false,
+		SealedBids:          false,
+		SealedBidTimeout:    10,
+		EnableHeight:        0,
+		MetricCoefficients:  DefaultMetricCoefficients(),
+	}
+}
+
+// ParamSetPairs implements paramstypes.ParamSet
+func (p *Params) ParamSetPairs() paramstypes.ParamSetPairs {
+	return paramstypes.ParamSetPairs{
+		paramstypes.NewParamSetPair(KeyWeightedGovTally, &p.WeightedGovTally, validateBool),
+		paramstypes.NewParamSetPair(KeyBaseWeight, &p.BaseWeight, validateWeight),
+		paramstypes.NewParamSetPair(KeyEpochLength, &p.EpochLength, validatePositive),
+		paramstypes.NewParamSetPair(KeyGracePeriod, &p.GracePeriodEpochs, validateNonNegative),
+		paramstypes.NewParamSetPair(KeyOutlierThreshold, &p.OutlierThreshold, validateNonNegative),
+		paramstypes.NewParamSetPair(KeyOutlierEpochs, &p.OutlierEpochs, validatePositive),
+		paramstypes.NewParamSetPair(KeyOutlierPenalty, &p.OutlierPenalty, validateNonNegative),
+		paramstypes.NewParamSetPair(KeyPenaltyRecovery, &p.PenaltyRecovery, validateNonNegative),
+		paramstypes.NewParamSetPair(KeyJailOutliers, &p.JailOutliers, validateBool),
+		paramstypes.NewParamSetPair(KeyOutlierJailDuration, &p.OutlierJailDuration, validatePositiveDuration),
+		paramstypes.NewParamSetPair(KeyCommitReveal, &p.CommitReveal, validateBool),
+		paramstypes.NewParamSetPair(KeyRejectFrontRuns, &p.RejectFrontRuns, validateBool),
+		paramstypes.NewParamSetPair(KeySealedBids, &p.SealedBids, validateBool),
+		paramstypes.NewParamSetPair(KeySealedBidTimeout, &p.SealedBidTimeout, validatePositive),
+		paramstypes.NewParamSetPair(KeyEnableHeight, &p.EnableHeight, validateNonNegative),
+		paramstypes.NewParamSetPair(KeyMetricCoefficients, &p.MetricCoefficients, validateMetricCoefficients),
+	}
+}
+
+// Validate performs a basic validation of the params by running each field through the
+// validator it is registered with in ParamSetPairs
+func (p Params) Validate() error {
+	for _, pair := range p.ParamSetPairs() {
+		if err := pair.ValidatorFn(reflect.ValueOf(pair.Value).Elem().Interface()); err != nil {
+			return fmt.Errorf("invalid %s: %w", pair.Key, err)
+		}
+	}
+	return nil
+}
+
+func validateBool(i interface{}) error {
+	if _, ok := i.(bool); !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	return nil
+}
+
+func validateNonNegative(i interface{}) error {
+	v, ok := i.(int64)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v < 0 {
+		return fmt.Errorf("parameter must not be negative: %d", v)
+	}
+	return nil
+}
+
+func validateWeight(i interface{}) error {
+	v, ok := i.(int64)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v < 0 || v > MaxWeight {
+		return fmt.Errorf("weight %d is out of range [0, %d]", v, MaxWeight)
+	}
+	return nil
+}
+
+func validatePositive(i interface{}) error {
+	v, ok := i.(int64)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v <= 0 {
+		return fmt.Errorf("parameter must be positive: %d", v)
+	}
+	return nil
+}
+
+func validatePositiveDuration(i interface{}) error {
+	v, ok := i.(time.Duration)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	if v <= 0 {
+		return fmt.Errorf("parameter must be positive: %s", v)
+	}
+	return nil
+}
+
+func validateMetricCoefficients(i interface{}) error {
+	v, ok := i.([]MetricCoefficient)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+	return ValidateMetricCoefficients(v)
+}
@@ -0,0 +1,64 @@
+package weightskeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/collections"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type queryServer struct {
+	k WeightsKeeper
+}
+
+var _ QueryServer = queryServer{}
+
+// NewQueryServer returns the ws query service implementation
+func NewQueryServer(k WeightsKeeper) QueryServer {
+	return queryServer{k: k}
+}
+
+// Explain returns the explanation recorded for the validator when the weights were last
+// aggregated, with the power the validator's stake and weight give it now
+func (q queryServer) Explain(ctx context.Context, req *QueryExplainRequest) (*QueryExplainResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty request")
+	}
+	valAddr, err := sdk.ValAddressFromBech32(req.Validator)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid validator address %q: %v", req.Validator, err)
+	}
+
+	val, err := q.k.stakingKeeper.GetValidator(ctx, valAddr)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "validator %s: %v", req.Validator, err)
+	}
+	consAddr, err := val.GetConsAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, err := q.k.Explanations.Get(ctx, consAddr)
+	if errors.Is(err, collections.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "no weight derived for %s yet", req.Validator)
+	}
+	if err != nil {
+		return nil, err
+	}
+	effectivePower, err := q.k.EffectivePower(ctx, consAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the effective power of %s: %w", req.Validator, err)
+	}
+
+	return &QueryExplainResponse{
+		Validator:        req.Validator,
+		ConsensusAddress: sdk.ConsAddress(consAddr).String(),
+		Explanation:      explanation,
+		Power:            val.ConsensusPower(sdk.DefaultPowerReduction),
+		EffectivePower:   effectivePower,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package weightskeeper
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	collcodec "cosmossdk.io/collections/codec"
+)
+
+// WeightRecord is the weight stored for a validator along with when it was set
+type WeightRecord struct {
+	Weight int64 `json:"weight"`
+	// Epoch is the epoch the weight was set in
+	Epoch int64 `json:"epoch"`
+	// Height is the height the weight was set at
+	Height int64 `json:"height"`
+}
+
+// WeightRecordValue encodes weight records in the store
+var WeightRecordValue collcodec.ValueCodec[WeightRecord] = jsonValue[WeightRecord]{name: "ws/WeightRecord"}
+
+// jsonValue encodes collection values as JSON, the encoding the ws store has always used
+type jsonValue[T any] struct {
+	name string
+}
+
+func (v jsonValue[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (v jsonValue[T]) Decode(b []byte) (T, error) {
+	var value T
+	if err := json.Unmarshal(b, &value); err != nil {
+		return value, fmt.Errorf("failed to decode %s: %w", v.name, err)
+	}
+	return value, nil
+}
+
+func (v jsonValue[T]) EncodeJSON(value T) ([]byte, error) {
+	return v.Encode(value)
+}
+
+func (v jsonValue[T]) DecodeJSON(b []byte) (T, error) {
+	return v.Decode(b)
+}
+
+func (v jsonValue[T]) Stringify(value T) string {
+	return fmt.Sprintf("%+v", value)
+}
+
+func (v jsonValue[T]) ValueType() string {
+	return v.name
+}
+
+// ValidatorMap holds a value per validator, keyed by consensus address bytes. The raw address
+// bytes are not valid UTF-8, so the map is encoded to JSON with the addresses in hex.
+type ValidatorMap map[string]int64
+
+func (m ValidatorMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	encoded := make(map[string]int64, len(m))
+	for validator, value := range m {
+		encoded[strings.ToUpper(hex.EncodeToString([]byte(validator)))] = value
+	}
+	return json.Marshal(encoded)
+}
+
+func (m *ValidatorMap) UnmarshalJSON(bz []byte) error {
+	var encoded map[string]int64
+	if err := json.Unmarshal(bz, &encoded); err != nil {
+		return err
+	}
+	if encoded == nil {
+		*m = nil
+		return nil
+	}
+	decoded := make(ValidatorMap, len(encoded))
+	for key, value := range encoded {
+		validator, err := hex.DecodeString(key)
+		if err != nil {
+			return fmt.Errorf("invalid validator address %q: %w", key, err)
+		}
+		decoded[string(validator)] = value
+	}
+	*m = decoded
+	return nil
+}
@@ -0,0 +1,42 @@
+package weightskeeper
+
+import (
+	"context"
+
+	"cosmossdk.io/collections"
+)
+
+// AddSealedBid records a sealed bid commitment made by the given bidder at the given height.
+func (k WeightsKeeper) AddSealedBid(ctx context.Context, commitment []byte, bidder string, height int64) error {
+	return k.SealedBids.Set(ctx, collections.Join(commitment, bidder), height)
+}
+
+// HasSealedBid returns whether the given bidder has an unrevealed sealed bid commitment.
+func (k WeightsKeeper) HasSealedBid(ctx context.Context, commitment []byte, bidder string) (bool, error) {
+	return k.SealedBids.Has(ctx, collections.Join(commitment, bidder))
+}
+
+// RemoveSealedBid removes a revealed sealed bid commitment.
+func (k WeightsKeeper) RemoveSealedBid(ctx context.Context, commitment []byte, bidder string) error {
+	return k.SealedBids.Remove(ctx, collections.Join(commitment, bidder))
+}
+
+// ExpireSealedBids removes the sealed bid commitments included at or before the given height.
+func (k WeightsKeeper) ExpireSealedBids(ctx context.Context, height int64) error {
+	var expired []collections.Pair[[]byte, string]
+	err := k.SealedBids.Walk(ctx, nil, func(key collections.Pair[[]byte, string], included int64) (bool, error) {
+		if included <= height {
+			expired = append(expired, key)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range expired {
+		if err := k.SealedBids.Remove(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
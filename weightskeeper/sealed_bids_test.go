@@ -0,0 +1,38 @@
+package weightskeeper_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealedBids(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+
+	first, second := []byte("commitment-1"), []byte("commitment-2")
+	require.NoError(t, keeper.AddSealedBid(ctx, first, "alice", 10))
+	require.NoError(t, keeper.AddSealedBid(ctx, second, "bob", 12))
+
+	has, err := keeper.HasSealedBid(ctx, first, "alice")
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// a commitment only belongs to the bidder who made it
+	has, err = keeper.HasSealedBid(ctx, first, "bob")
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// commitments included at or before the given height expire
+	require.NoError(t, keeper.ExpireSealedBids(ctx, 10))
+	has, err = keeper.HasSealedBid(ctx, first, "alice")
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = keeper.HasSealedBid(ctx, second, "bob")
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.NoError(t, keeper.RemoveSealedBid(ctx, second, "bob"))
+	has, err = keeper.HasSealedBid(ctx, second, "bob")
+	require.NoError(t, err)
+	require.False(t, has)
+}
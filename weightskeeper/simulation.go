@@ -0,0 +1,151 @@
+package weightskeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"cosmossdk.io/math"
+	weight_shift "github.com/ciprianmuja/weight-shift"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+)
+
+var _ module.AppModuleSimulation = AppModule{}
+
+// Simulation param keys
+const (
+	simKeyParams = "ws_params"
+
+	OpWeightUpdateParams        = "op_weight_ws_update_params"
+	OpWeightSealedBidCommitment = "op_weight_ws_sealed_bid_commitment"
+)
+
+// Default weights of the ws operations
+const (
+	DefaultWeightUpdateParams        = 5
+	DefaultWeightSealedBidCommitment = 50
+)
+
+const (
+	opUpdateParams        = "update_params"
+	opSealedBidCommitment = "sealed_bid_commitment"
+)
+
+// RandomParams returns valid ws params with random values
+func RandomParams(r *rand.Rand) Params {
+	return Params{
+		WeightedGovTally:    r.Intn(2) == 0,
+		BaseWeight:          r.Int63n(MaxWeight + 1),
+		EpochLength:         1 + r.Int63n(200),
+		GracePeriodEpochs:   r.Int63n(20),
+		OutlierThreshold:    r.Int63n(MaxWeight + 1),
+		OutlierEpochs:       1 + r.Int63n(5),
+		OutlierPenalty:      r.Int63n(20),
+		PenaltyRecovery:     r.Int63n(5),
+		JailOutliers:        r.Intn(2) == 0,
+		OutlierJailDuration: time.Duration(1+r.Int63n(3600)) * time.Second,
+		CommitReveal:        r.Intn(2) == 0,
+		RejectFrontRuns:     r.Intn(2) == 0,
+		SealedBids:          r.Intn(2) == 0,
+		SealedBidTimeout:    1 + r.Int63n(20),
+		EnableHeight:        r.Int63n(100),
+		MetricCoefficients:  randomMetricCoefficients(r),
+	}
+}
+
+// randomMetricCoefficients splits one between the metrics, in hundredths
+func randomMetricCoefficients(r *rand.Rand) []MetricCoefficient {
+	coefficients := make([]MetricCoefficient, len(metricNames))
+	left := int64(100)
+	for i, metric := range metricNames {
+		share := left
+		if i < len(metricNames)-1 {
+			share = r.Int63n(left + 1)
+		}
+		left -= share
+		coefficients[i] = MetricCoefficient{Metric: metric, Coefficient: math.LegacyNewDecWithPrec(share, 2)}
+	}
+	return coefficients
+}
+
+// GenerateGenesisState creates a randomized ws genesis state
+func (AppModule) GenerateGenesisState(simState *module.SimulationState) {
+	var params Params
+	simState.AppParams.GetOrGenerate(simKeyParams, &params, simState.Rand, func(r *rand.Rand) {
+		params = RandomParams(r)
+	})
+
+	genesis := GenesisState{Params: params}
+	bz, err := json.MarshalIndent(&genesis, "", " ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Selected randomly generated ws parameters:\n%s\n", bz)
+	simState.GenState[weight_shift.ModuleName] = bz
+}
+
+// RegisterStoreDecoder registers a decoder for the ws store
+func (am AppModule) RegisterStoreDecoder(sdr simtypes.StoreDecoderRegistry) {
+	sdr[weight_shift.StoreKey] = simtypes.NewStoreDecoderFuncFromCollectionsSchema(am.keeper.Schema)
+}
+
+// WeightedOperations returns the ws operations with their weights. The ws module has no
+// msgs of its own, so its operations act on the keeper the way governance and the sealed
+// bid ante decorator do.
+func (am AppModule) WeightedOperations(simState module.SimulationState) []simtypes.WeightedOperation {
+	var weightUpdateParams, weightSealedBidCommitment int
+	simState.AppParams.GetOrGenerate(OpWeightUpdateParams, &weightUpdateParams, nil, func(*rand.Rand) {
+		weightUpdateParams = DefaultWeightUpdateParams
+	})
+	simState.AppParams.GetOrGenerate(OpWeightSealedBidCommitment, &weightSealedBidCommitment, nil, func(*rand.Rand) {
+		weightSealedBidCommitment = DefaultWeightSealedBidCommitment
+	})
+
+	return []simtypes.WeightedOperation{
+		simulation.NewWeightedOperation(weightUpdateParams, SimulateUpdateParams(am.keeper)),
+		simulation.NewWeightedOperation(weightSealedBidCommitment, SimulateSealedBidCommitment(am.keeper)),
+	}
+}
+
+// SimulateUpdateParams sets random ws params, as a passed param change proposal would
+func SimulateUpdateParams(k WeightsKeeper) simtypes.Operation {
+	return func(r *rand.Rand, _ *baseapp.BaseApp, ctx sdk.Context, _ []simtypes.Account, _ string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		params := RandomParams(r)
+		if err := params.Validate(); err != nil {
+			return simtypes.NoOpMsg(weight_shift.ModuleName, opUpdateParams, "invalid params"), nil, err
+		}
+		k.SetParams(ctx, params)
+
+		bz, err := json.Marshal(params)
+		if err != nil {
+			return simtypes.NoOpMsg(weight_shift.ModuleName, opUpdateParams, "failed to encode params"), nil, err
+		}
+		return simtypes.NewOperationMsgBasic(weight_shift.ModuleName, opUpdateParams, "", true, bz), nil, nil
+	}
+}
+
+// SimulateSealedBidCommitment registers a random account's claim to a name by committing it
+// to a sealed nameservice bid, as the sealed bid ante decorator does for a signed commitment
+// tx. The commitment is never revealed, so it expires after the sealed bid timeout.
+func SimulateSealedBidCommitment(k WeightsKeeper) simtypes.Operation {
+	return func(r *rand.Rand, _ *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, _ string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		if !k.GetParams(ctx).SealedBids {
+			return simtypes.NoOpMsg(weight_shift.ModuleName, opSealedBidCommitment, "sealed bids are disabled"), nil, nil
+		}
+
+		bidder, _ := simtypes.RandomAcc(r, accs)
+		commitment := make([]byte, 32)
+		r.Read(commitment)
+		if err := k.AddSealedBid(ctx, commitment, bidder.Address.String(), ctx.BlockHeight()); err != nil {
+			return simtypes.NoOpMsg(weight_shift.ModuleName, opSealedBidCommitment, "failed to add sealed bid"), nil, err
+		}
+		return simtypes.NewOperationMsgBasic(weight_shift.ModuleName, opSealedBidCommitment, "", true, commitment), nil, nil
+	}
+}
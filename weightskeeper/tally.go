@@ -0,0 +1,95 @@
+package weightskeeper
+
+import (
+	"context"
+
+	"cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/x/gov/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+var _ types.StakingKeeper = WeightedTallyStakingKeeper{}
+
+// WeightedTallyStakingKeeper is the staking keeper handed to x/gov. The gov tally derives every
+// vote's power from the bonded tokens of the validators it iterates, so scaling those tokens by
+// the ws weight multiplier weights both the validator's own vote and the votes its delegators
+// inherit from it. With the WeightedGovTally param disabled it behaves like the wrapped keeper.
+type WeightedTallyStakingKeeper struct {
+	types.StakingKeeper
+	keeper WeightsKeeper
+}
+
+// NewWeightedTallyStakingKeeper wraps the given staking keeper for use by the gov keeper.
+func NewWeightedTallyStakingKeeper(sk types.StakingKeeper, keeper WeightsKeeper) WeightedTallyStakingKeeper {
+	return WeightedTallyStakingKeeper{
+		StakingKeeper: sk,
+		keeper:        keeper,
+	}
+}
+
+// IterateBondedValidatorsByPower iterates the bonded validators, reporting their bonded tokens
+// scaled by their weight multiplier when weighted tallying is enabled.
+func (w WeightedTallyStakingKeeper) IterateBondedValidatorsByPower(
+	ctx context.Context, fn func(index int64, validator stakingtypes.ValidatorI) (stop bool),
+) error {
+	if !w.keeper.GetParams(ctx).WeightedGovTally {
+		return w.StakingKeeper.IterateBondedValidatorsByPower(ctx, fn)
+	}
+
+	var err error
+	iterErr := w.StakingKeeper.IterateBondedValidatorsByPower(ctx, func(index int64, validator stakingtypes.ValidatorI) bool {
+		var weighted stakingtypes.ValidatorI
+		weighted, err = w.weightValidator(ctx, validator)
+		if err != nil {
+			return true
+		}
+		return fn(index, weighted)
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	return err
+}
+
+// TotalBondedTokens returns the sum of the weighted bonded tokens when weighted tallying is
+// enabled, so quorum is computed against the same scale as the votes.
+func (w WeightedTallyStakingKeeper) TotalBondedTokens(ctx context.Context) (math.Int, error) {
+	if !w.keeper.GetParams(ctx).WeightedGovTally {
+		return w.StakingKeeper.TotalBondedTokens(ctx)
+	}
+
+	total := math.ZeroInt()
+	err := w.IterateBondedValidatorsByPower(ctx, func(_ int64, validator stakingtypes.ValidatorI) bool {
+		total = total.Add(validator.GetBondedTokens())
+		return false
+	})
+	if err != nil {
+		return math.Int{}, err
+	}
+	return total, nil
+}
+
+func (w WeightedTallyStakingKeeper) weightValidator(ctx context.Context, validator stakingtypes.ValidatorI) (stakingtypes.ValidatorI, error) {
+	consAddr, err := validator.GetConsAddr()
+	if err != nil {
+		return nil, err
+	}
+	multiplier, err := w.keeper.WeightMultiplier(ctx, consAddr)
+	if err != nil {
+		return nil, err
+	}
+	return weightedValidator{
+		ValidatorI:   validator,
+		bondedTokens: multiplier.MulInt(validator.GetBondedTokens()).TruncateInt(),
+	}, nil
+}
+
+// weightedValidator overrides the bonded tokens of a validator with its weighted amount
+type weightedValidator struct {
+	stakingtypes.ValidatorI
+	bondedTokens math.Int
+}
+
+func (v weightedValidator) GetBondedTokens() math.Int {
+	return v.bondedTokens
+}
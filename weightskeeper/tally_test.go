@@ -0,0 +1,121 @@
+package weightskeeper_test
+
+import (
+	"context"
+	"testing"
+
+	"cosmossdk.io/collections"
+	"cosmossdk.io/core/address"
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	"github.com/ciprianmuja/weight-shift/testutils"
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	addresscodec "github.com/cosmos/cosmos-sdk/codec/address"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	v1 "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccountKeeper provides the few account keeper methods the gov keeper needs to be built
+type mockAccountKeeper struct{}
+
+func (mockAccountKeeper) AddressCodec() address.Codec {
+	return addresscodec.NewBech32Codec(sdk.Bech32MainPrefix)
+}
+func (mockAccountKeeper) GetAccount(context.Context, sdk.AccAddress) sdk.AccountI { return nil }
+func (mockAccountKeeper) GetModuleAddress(name string) sdk.AccAddress {
+	return authtypes.NewModuleAddress(name)
+}
+func (mockAccountKeeper) GetModuleAccount(context.Context, string) sdk.ModuleAccountI { return nil }
+func (mockAccountKeeper) SetModuleAccount(context.Context, sdk.ModuleAccountI)        {}
+
+func TestWeightedGovTally(t *testing.T) {
+	// the larger validator votes no, the smaller one yes but carries the maximum weight
+	noVoter := sdk.ValAddress("no_voter____________")
+	yesVoter := sdk.ValAddress("yes_voter___________")
+	yesValidator := newValidator(yesVoter, 40)
+	sk := &mockStakingKeeper{validators: []stakingtypes.Validator{
+		newValidator(noVoter, 60),
+		yesValidator,
+	}}
+
+	govKey := storetypes.NewKVStoreKey(govtypes.StoreKey)
+	ctx, keeper := setupKeeper(t, sk, govKey)
+	encCfg := testutils.MakeTestEncodingConfig()
+	v1.RegisterInterfaces(encCfg.InterfaceRegistry)
+
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(consAddr(yesValidator)): 55}))
+
+	govKeeper := govkeeper.NewKeeper(
+		encCfg.Marshaler,
+		runtime.NewKVStoreService(govKey),
+		mockAccountKeeper{},
+		nil,
+		weightskeeper.NewWeightedTallyStakingKeeper(sk, keeper),
+		nil,
+		baseapp.NewMsgServiceRouter(),
+		govtypes.DefaultConfig(),
+		authtypes.NewModuleAddress(govtypes.ModuleName).String(),
+	)
+	require.NoError(t, govKeeper.Params.Set(ctx, v1.DefaultParams()))
+
+	votes := map[string]v1.VoteOption{
+		sdk.AccAddress(noVoter).String():  v1.OptionNo,
+		sdk.AccAddress(yesVoter).String(): v1.OptionYes,
+	}
+	tally := func() (bool, v1.TallyResult) {
+		proposal := v1.Proposal{Id: 1}
+		for voter, option := range votes {
+			addr := sdk.MustAccAddressFromBech32(voter)
+			require.NoError(t, govKeeper.Votes.Set(ctx, collections.Join(uint64(1), addr),
+				v1.NewVote(1, addr, v1.NewNonSplitVoteOption(option), "")))
+		}
+
+		passes, _, result, err := govKeeper.Tally(ctx, proposal)
+		require.NoError(t, err)
+		return passes, result
+	}
+
+	// stake only tally: 60 no against 40 yes
+	passes, result := tally()
+	require.False(t, passes)
+	require.Equal(t, "40", result.YesCount)
+	require.Equal(t, "60", result.NoCount)
+
+	// weighted tally: 60 no against 40 * 1.55 = 62 yes
+	params := weightskeeper.DefaultParams()
+	params.WeightedGovTally = true
+	keeper.SetParams(ctx, params)
+	passes, result = tally()
+	require.True(t, passes)
+	require.Equal(t, "62", result.YesCount)
+	require.Equal(t, "60", result.NoCount)
+
+	// a delegator holding half the shares of the weighted validator votes no: its shares are
+	// taken off the validator's vote and count at the validator's weighted tokens
+	delegator := sdk.AccAddress("delegator___________")
+	sk.delegations = map[string][]stakingtypes.Delegation{
+		delegator.String(): {stakingtypes.NewDelegation(delegator.String(), yesVoter.String(), math.LegacyNewDec(20))},
+	}
+	votes[delegator.String()] = v1.OptionNo
+
+	// weighted tally: 20 / 40 * 62 = 31 yes against 60 + 31 = 91 no
+	passes, result = tally()
+	require.False(t, passes)
+	require.Equal(t, "31", result.YesCount)
+	require.Equal(t, "91", result.NoCount)
+
+	// stake only tally: 20 yes against 60 + 20 = 80 no
+	params.WeightedGovTally = false
+	keeper.SetParams(ctx, params)
+	passes, result = tally()
+	require.False(t, passes)
+	require.Equal(t, "20", result.YesCount)
+	require.Equal(t, "80", result.NoCount)
+}
@@ -2,34 +2,91 @@ package weightskeeper
 
 import (
 	"context"
+	"errors"
 	weight_shift "github.com/ciprianmuja/weight-shift"
-	"github.com/cosmos/cosmos-sdk/x/gov/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"sort"
 
 	"cosmossdk.io/collections"
 	"cosmossdk.io/core/address"
 	storetypes "cosmossdk.io/core/store"
+	"cosmossdk.io/math"
 	"github.com/cosmos/cosmos-sdk/codec"
 )
 
+// MaxWeight is the highest weight a validator can be given, i.e. a 55% bonus on its stake
+const MaxWeight int64 = 55
+
 type WeightsKeeper struct {
 	cdc          codec.BinaryCodec
 	addressCodec address.Codec
 	authority    string
+	paramSpace   paramstypes.Subspace
+	storeService storetypes.KVStoreService
+
+	stakingKeeper  StakingKeeper
+	slashingKeeper SlashingKeeper
 
 	// state management
-	Schema        collections.Schema
-	Weights       collections.Map[string, int64]
-	stakingKeeper types.StakingKeeper
+	Schema collections.Schema
+	// Weights holds the weight record of each validator. Like every validator keyed
+	// collection of the module it is keyed by consensus address.
+	Weights collections.Map[sdk.ConsAddress, WeightRecord]
+	// BondingHeights holds the height at which each validator was first bonded
+	BondingHeights collections.Map[sdk.ConsAddress, int64]
+	// Deviations holds, per epoch and validator, the largest deviation of the validator's
+	// reported weights from the aggregated median
+	Deviations collections.Map[collections.Pair[int64, sdk.ConsAddress], int64]
+	// OutlierStreaks holds the number of consecutive outlier epochs of each validator
+	OutlierStreaks collections.Map[sdk.ConsAddress, int64]
+	// Penalties holds the weight deducted from each validator for dishonest reporting
+	Penalties collections.Map[sdk.ConsAddress, int64]
+	// Commitments holds the weights commitment of each validator's last vote extension,
+	// keyed by consensus address, when running in commit-reveal mode
+	Commitments collections.Map[[]byte, []byte]
+	// SealedBids holds the height at which each sealed bid commitment was included, keyed by
+	// commitment and bidder address
+	SealedBids collections.Map[collections.Pair[[]byte, string], int64]
+	// Explanations holds how the weight of each validator was derived when the weights were
+	// last aggregated
+	Explanations collections.Map[sdk.ConsAddress, Explanation]
 }
 
 // NewWeightsKeeper creates a new Keeper instance
-func NewWeightsKeeper(cdc codec.BinaryCodec, addressCodec address.Codec, storeService storetypes.KVStoreService) WeightsKeeper {
+func NewWeightsKeeper(cdc codec.BinaryCodec, addressCodec address.Codec, storeService storetypes.KVStoreService,
+	paramSpace paramstypes.Subspace, stakingKeeper StakingKeeper, slashingKeeper SlashingKeeper) WeightsKeeper {
+	// set KeyTable if it has not already been set
+	if !paramSpace.HasKeyTable() {
+		paramSpace = paramSpace.WithKeyTable(ParamKeyTable())
+	}
 
 	sb := collections.NewSchemaBuilder(storeService)
 	k := WeightsKeeper{
 		cdc:          cdc,
 		addressCodec: addressCodec,
-		Weights:      collections.NewMap(sb, weight_shift.WeightsKey, "weights", collections.StringKey, collections.Int64Value),
+		paramSpace:   paramSpace,
+		storeService: storeService,
+
+		stakingKeeper:  stakingKeeper,
+		slashingKeeper: slashingKeeper,
+
+		Weights: collections.NewMap(sb, weight_shift.WeightsKey, "weights", sdk.ConsAddressKey, WeightRecordValue),
+		BondingHeights: collections.NewMap(sb, weight_shift.BondingHeightsKey, "bonding_heights",
+			sdk.ConsAddressKey, collections.Int64Value),
+		Deviations: collections.NewMap(sb, weight_shift.DeviationsKey, "deviations",
+			collections.PairKeyCodec(collections.Int64Key, sdk.ConsAddressKey), collections.Int64Value),
+		OutlierStreaks: collections.NewMap(sb, weight_shift.OutlierStreaksKey, "outlier_streaks",
+			sdk.ConsAddressKey, collections.Int64Value),
+		Penalties: collections.NewMap(sb, weight_shift.PenaltiesKey, "penalties",
+			sdk.ConsAddressKey, collections.Int64Value),
+		Commitments: collections.NewMap(sb, weight_shift.CommitmentsKey, "commitments",
+			collections.BytesKey, collections.BytesValue),
+		SealedBids: collections.NewMap(sb, weight_shift.SealedBidsKey, "sealed_bids",
+			collections.PairKeyCodec(collections.BytesKey, collections.StringKey), collections.Int64Value),
+		Explanations: collections.NewMap(sb, weight_shift.ExplanationsKey, "explanations",
+			sdk.ConsAddressKey, ExplanationValue),
 	}
 
 	schema, err := sb.Build()
@@ -47,10 +104,11 @@ func (k WeightsKeeper) GetAuthority() string {
 	return k.authority
 }
 
+// GetWeights returns the weight of every validator, keyed by consensus address bytes
 func (k WeightsKeeper) GetWeights(ctx context.Context) (map[string]int64, error) {
-	var weights map[string]int64
-	err := k.Weights.Walk(ctx, nil, func(key string, value int64) (bool, error) {
-		weights[key] = value
+	weights := make(map[string]int64)
+	err := k.Weights.Walk(ctx, nil, func(consAddr sdk.ConsAddress, record WeightRecord) (bool, error) {
+		weights[string(consAddr)] = record.Weight
 		return false, nil
 	})
 	if err != nil {
@@ -59,16 +117,191 @@ func (k WeightsKeeper) GetWeights(ctx context.Context) (map[string]int64, error)
 	return weights, nil
 }
 
-func (k WeightsKeeper) SetWeights(ctx context.Context, weights map[string]int64) error {
-	for b, q := range weights {
-		err := k.Weights.Set(ctx, b, q)
+// GetWeight returns the weight stored for the given validator, or zero if it has none yet.
+func (k WeightsKeeper) GetWeight(ctx context.Context, consAddr sdk.ConsAddress) (int64, error) {
+	record, err := k.Weights.Get(ctx, consAddr)
+	if errors.Is(err, collections.ErrNotFound) {
+		return 0, nil
+	}
+	return record.Weight, err
+}
+
+// setWeight stores the weight of the given validator as set in the current epoch and height
+func (k WeightsKeeper) setWeight(ctx context.Context, consAddr sdk.ConsAddress, weight int64) error {
+	return k.Weights.Set(ctx, consAddr, WeightRecord{
+		Weight: weight,
+		Epoch:  k.CurrentEpoch(ctx),
+		Height: sdk.UnwrapSDKContext(ctx).BlockHeight(),
+	})
+}
+
+// WeightMultiplier returns the factor a validator's stake is scaled by when its weight is
+// applied: a weight of 12 is a 12% bonus, i.e. a multiplier of 1.12.
+func (k WeightsKeeper) WeightMultiplier(ctx context.Context, consAddr sdk.ConsAddress) (math.LegacyDec, error) {
+	weight, err := k.GetWeight(ctx, consAddr)
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	return math.LegacyNewDec(100 + weight).QuoInt64(100), nil
+}
+
+// EffectivePower returns the consensus power of the given validator once its weight multiplier
+// is applied.
+func (k WeightsKeeper) EffectivePower(ctx context.Context, consAddr sdk.ConsAddress) (int64, error) {
+	val, err := k.stakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+	if err != nil {
+		return 0, err
+	}
+	multiplier, err := k.WeightMultiplier(ctx, consAddr)
+	if err != nil {
+		return 0, err
+	}
+	return multiplier.MulInt64(val.ConsensusPower(sdk.DefaultPowerReduction)).TruncateInt64(), nil
+}
+
+// GetValidatorByConsAddr returns the validator with the given consensus address.
+func (k WeightsKeeper) GetValidatorByConsAddr(ctx context.Context, consAddr sdk.ConsAddress) (stakingtypes.Validator, error) {
+	return k.stakingKeeper.GetValidatorByConsAddr(ctx, consAddr)
+}
+
+// BondedValidators returns the consensus addresses of the bonded validators, by decreasing
+// power
+func (k WeightsKeeper) BondedValidators(ctx context.Context) ([]sdk.ConsAddress, error) {
+	var validators []sdk.ConsAddress
+	var iterErr error
+	err := k.stakingKeeper.IterateBondedValidatorsByPower(ctx, func(_ int64, val stakingtypes.ValidatorI) bool {
+		consAddr, err := val.GetConsAddr()
 		if err != nil {
+			iterErr = err
+			return true
+		}
+		validators = append(validators, consAddr)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return validators, iterErr
+}
+
+// knownValidators returns the given values without the ones of validators the staking module
+// does not know, such as a validator removed since it was reported. Every value dropped is
+// logged.
+func (k WeightsKeeper) knownValidators(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	known := make(map[string]int64, len(values))
+	for _, validator := range sortedKeys(values) {
+		_, err := k.stakingKeeper.GetValidatorByConsAddr(ctx, sdk.ConsAddress(validator))
+		if errors.Is(err, stakingtypes.ErrNoValidatorFound) {
+			sdk.UnwrapSDKContext(ctx).Logger().Info("dropping a value reported for an unknown validator", "validator", sdk.ConsAddress(validator).String())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		known[validator] = values[validator]
+	}
+	return known, nil
+}
+
+// GetParams returns the current ws module params, falling back to the defaults for any
+// param that has not been set yet.
+func (k WeightsKeeper) GetParams(ctx context.Context) Params {
+	params := DefaultParams()
+	k.paramSpace.GetParamSetIfExists(sdk.UnwrapSDKContext(ctx), &params)
+	return params
+}
+
+// SetParams sets the ws module params.
+func (k WeightsKeeper) SetParams(ctx context.Context, params Params) {
+	k.paramSpace.SetParamSet(sdk.UnwrapSDKContext(ctx), &params)
+}
+
+// SetWeights stores the given weights, keyed by consensus address bytes, emitting an
+// EventWeightsUpdated for every validator whose weight changes.
+func (k WeightsKeeper) SetWeights(ctx context.Context, weights map[string]int64) error {
+	sdkCtx := sdk.UnwrapSDKContext(ctx)
+	epoch := k.CurrentEpoch(ctx)
+
+	for _, validator := range sortedKeys(weights) {
+		consAddr := sdk.ConsAddress(validator)
+		weight := weights[validator]
+		old, err := k.Weights.Get(ctx, consAddr)
+		if err != nil && !errors.Is(err, collections.ErrNotFound) {
+			return err
+		}
+		if err == nil && old.Weight == weight {
+			continue
+		}
+		if err := k.setWeight(ctx, consAddr, weight); err != nil {
+			return err
+		}
+		if err := sdkCtx.EventManager().EmitTypedEvent(&EventWeightsUpdated{
+			Epoch:     epoch,
+			Validator: consAddr.String(),
+			OldWeight: old.Weight,
+			NewWeight: weight,
+		}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (k WeightsKeeper) GetValidatorsUptime(ctx context.Context) {
+// CurrentEpoch returns the epoch the current block belongs to
+func (k WeightsKeeper) CurrentEpoch(ctx context.Context) int64 {
+	return sdk.UnwrapSDKContext(ctx).BlockHeight() / k.GetParams(ctx).EpochLength
+}
+
+// ApplyGracePeriod gives every validator that bonded less than GracePeriodEpochs epochs ago
+// the median weight of the established validators, so that newcomers without any history
+// keep a baseline influence until their metric based weight takes over. Without established
+// validators the newcomers get the base weight.
+func (k WeightsKeeper) ApplyGracePeriod(ctx context.Context, weights map[string]int64) (map[string]int64, error) {
+	params := k.GetParams(ctx)
+	epoch := k.CurrentEpoch(ctx)
 
+	newcomers := make(map[string]bool)
+	err := k.BondingHeights.Walk(ctx, nil, func(consAddr sdk.ConsAddress, height int64) (bool, error) {
+		if epoch-height/params.EpochLength < params.GracePeriodEpochs {
+			newcomers[string(consAddr)] = true
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(newcomers) == 0 {
+		return weights, nil
+	}
+
+	var established []int64
+	for validator, weight := range weights {
+		if !newcomers[validator] {
+			established = append(established, weight)
+		}
+	}
+	baseline := params.BaseWeight
+	if len(established) > 0 {
+		baseline = median(established)
+	}
+
+	result := make(map[string]int64, len(weights)+len(newcomers))
+	for validator, weight := range weights {
+		result[validator] = weight
+	}
+	for validator := range newcomers {
+		result[validator] = baseline
+	}
+	return result, nil
+}
+
+// median returns the median of the given values, the lower one for an even number of values
+func median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)/2]
 }
@@ -0,0 +1,103 @@
+package weightskeeper_test
+
+import (
+	"testing"
+
+	"github.com/ciprianmuja/weight-shift/weightskeeper"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyGracePeriod(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+	params := weightskeeper.DefaultParams()
+	params.EpochLength = 10
+	params.GracePeriodEpochs = 2
+	keeper.SetParams(ctx, params)
+
+	newcomer := sdk.ConsAddress("newcomer____________")
+	require.NoError(t, keeper.AfterValidatorBonded(ctx.WithBlockHeight(100), newcomer, nil))
+
+	weights := map[string]int64{
+		"val1":           10,
+		"val2":           30,
+		"val3":           50,
+		string(newcomer): 0,
+	}
+
+	// within the grace period the newcomer gets the median of the established validators
+	graced, err := keeper.ApplyGracePeriod(ctx.WithBlockHeight(115), weights)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), graced[string(newcomer)])
+	require.Equal(t, int64(10), graced["val1"])
+
+	// once the grace period is over its own weight is used
+	graced, err = keeper.ApplyGracePeriod(ctx.WithBlockHeight(120), weights)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), graced[string(newcomer)])
+
+	// bonding again later does not restart the grace period
+	require.NoError(t, keeper.AfterValidatorBonded(ctx.WithBlockHeight(200), newcomer, nil))
+	height, err := keeper.BondingHeights.Get(ctx, newcomer)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), height)
+
+	// without established validators the newcomers get the base weight
+	params.BaseWeight = 7
+	keeper.SetParams(ctx, params)
+	graced, err = keeper.ApplyGracePeriod(ctx.WithBlockHeight(115), map[string]int64{string(newcomer): 40})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{string(newcomer): 7}, graced)
+}
+
+func TestGenesisValidatorsEstablished(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+	val1, val2 := sdk.ConsAddress("val1________________"), sdk.ConsAddress("val2________________")
+
+	// staking bonds the genesis validators ahead of the ws genesis
+	require.NoError(t, keeper.AfterValidatorBonded(ctx, val1, nil))
+	require.NoError(t, keeper.AfterValidatorBonded(ctx, val2, nil))
+	require.NoError(t, keeper.InitGenesis(ctx, *weightskeeper.DefaultGenesisState()))
+
+	// they keep their own weights from the first epoch
+	weights := map[string]int64{string(val1): 10, string(val2): 40}
+	graced, err := keeper.ApplyGracePeriod(ctx.WithBlockHeight(150), weights)
+	require.NoError(t, err)
+	require.Equal(t, weights, graced)
+}
+
+func TestSetWeightsEvents(t *testing.T) {
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{})
+	ctx = ctx.WithBlockHeight(250).WithEventManager(sdk.NewEventManager())
+	val1, val2 := sdk.ConsAddress("val1________________"), sdk.ConsAddress("val2________________")
+
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(val1): 10, string(val2): 20}))
+	require.Len(t, ctx.EventManager().Events(), 2)
+
+	// only the validators whose weight changes get an event
+	ctx = ctx.WithEventManager(sdk.NewEventManager())
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(val1): 10, string(val2): 25}))
+	events := ctx.EventManager().Events()
+	event, err := sdk.TypedEventToEvent(&weightskeeper.EventWeightsUpdated{
+		Epoch:     2,
+		Validator: val2.String(),
+		OldWeight: 20,
+		NewWeight: 25,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.Events{event}, events)
+}
+
+func TestEffectivePower(t *testing.T) {
+	val := newValidator(sdk.ValAddress("validator___________"), 200_000_000)
+	ctx, keeper := setupKeeper(t, &mockStakingKeeper{validators: []stakingtypes.Validator{val}})
+
+	require.NoError(t, keeper.SetWeights(ctx, map[string]int64{string(consAddr(val)): 50}))
+	power, err := keeper.EffectivePower(ctx, consAddr(val))
+	require.NoError(t, err)
+	require.Equal(t, int64(300), power)
+
+	_, err = keeper.EffectivePower(ctx, sdk.ConsAddress("val1________________"))
+	require.Error(t, err)
+}